@@ -0,0 +1,532 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"config-checker/pkg/checker"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// runCollect gathers the same artifacts as openfaas-diagnostics.sh through
+// the Kubernetes API, for every installation and function namespace which
+// is discovered, and writes them with the checker's report into a .tgz,
+// for the collect subcommand
+func runCollect(args []string) {
+	var (
+		kubeconfig            string
+		openfaasCoreNamespace string
+		outputDir             string
+		logLines              int64
+		configFile            string
+	)
+
+	flags := flag.NewFlagSet("collect", flag.ExitOnError)
+	flags.StringVar(&kubeconfig, "kubeconfig", "$HOME/.kube/config", "Path to KUBECONFIG")
+	flags.StringVar(&openfaasCoreNamespace, "openfaas-namespace", "", "Namespace for the OpenFaaS installation, by default every installation in the cluster is collected")
+	flags.StringVar(&outputDir, "output-dir", ".", "Directory to write the support bundle to")
+	flags.Int64Var(&logLines, "log-lines", 1000, "Number of lines to read from the end of each core component's logs")
+	flags.StringVar(&configFile, "config", "", "Path to a YAML file to override the thresholds used by the rules")
+	flags.Parse(args)
+
+	config := loadConfig(configFile)
+
+	clientset, dynamicClient, err := getClientset(kubeconfig)
+	if err != nil {
+		log.Fatalf("Error building clientset: %s", err)
+	}
+
+	ctx := context.Background()
+
+	namespaces := []string{openfaasCoreNamespace}
+	if len(openfaasCoreNamespace) == 0 {
+		namespaces = discoverInstallations(ctx, clientset)
+	}
+
+	var snapshots []*checker.ClusterSnapshot
+	for _, namespace := range namespaces {
+		snapshot, err := checker.NewCollector(clientset, namespace).
+			WithDynamicClient(dynamicClient).
+			Collect(ctx)
+		if err != nil {
+			log.Fatalf("Error collecting OpenFaaS configuration: %s. Exiting", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	checker.AssignNamespaces(snapshots)
+
+	// Each installation is written to a directory named after its
+	// namespace, which is openfaas as for openfaas-diagnostics.sh
+	var bundles []*bundle
+	for _, snapshot := range snapshots {
+		report := newReport(snapshot, config, checker.Analyze(snapshot, config))
+
+		b := newBundle(clientset, dynamicClient, snapshot)
+		b.logLines = logLines
+		b.collect(ctx)
+
+		var text, jsonReport bytes.Buffer
+		writeTextReport(&text, report)
+		if err := writeJSONReports(&jsonReport, []Report{report}); err != nil {
+			log.Fatalf("Error writing report: %s", err)
+		}
+		b.add("18-checker-report.txt", text.Bytes())
+		b.add("19-checker-report.json", jsonReport.Bytes())
+
+		bundles = append(bundles, b)
+	}
+
+	now := time.Now()
+	path := filepath.Join(outputDir, fmt.Sprintf("openfaas-%s.tgz", now.Format("2006-01-02_15_04_05")))
+
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("Error creating %s: %s", path, err)
+	}
+	if err := writeBundles(f, bundles, now); err != nil {
+		f.Close()
+		log.Fatalf("Error writing %s: %s", path, err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("Error writing %s: %s", path, err)
+	}
+
+	for _, b := range bundles {
+		if len(b.errors) > 0 {
+			log.Printf("%d artifact(s) could not be collected, see %s/collect-errors.txt", len(b.errors), b.dir())
+		}
+	}
+	fmt.Printf("Wrote %s\n", path)
+}
+
+// bundleFile is a file within the support bundle
+type bundleFile struct {
+	Name string
+	Data []byte
+}
+
+// pdbFile is written with the core namespace's PodDisruptionBudgets, even
+// when there are none, so that offline mode can tell them from a bundle
+// where they could not be listed
+const pdbFile = "20-openfaas-pdb.yaml"
+
+// bundle collects the artifacts for a support bundle. An artifact which
+// can not be read, such as a list which is denied by RBAC, is recorded in
+// collect-errors.txt and the rest of the bundle is still collected.
+type bundle struct {
+	client   kubernetes.Interface
+	dynamic  dynamic.Interface
+	snapshot *checker.ClusterSnapshot
+	logLines int64
+
+	files  []bundleFile
+	errors []string
+}
+
+func newBundle(client kubernetes.Interface, dynamicClient dynamic.Interface, snapshot *checker.ClusterSnapshot) *bundle {
+	return &bundle{
+		client:   client,
+		dynamic:  dynamicClient,
+		snapshot: snapshot,
+	}
+}
+
+func (b *bundle) add(name string, data []byte) {
+	b.files = append(b.files, bundleFile{Name: name, Data: data})
+}
+
+func (b *bundle) addError(name string, err error) {
+	b.errors = append(b.errors, fmt.Sprintf("%s: %s", name, err))
+}
+
+// addList writes the items of one or more lists as a single List, in the
+// same form as kubectl get -o yaml, so that the bundle can be checked
+// again with --from-dir
+func (b *bundle) addList(name string, lists []runtime.Object) {
+	if len(lists) == 0 {
+		return
+	}
+
+	items := []runtime.Object{}
+	for _, list := range lists {
+		listItems, err := meta.ExtractList(list)
+		if err != nil {
+			b.addError(name, err)
+			return
+		}
+		items = append(items, listItems...)
+	}
+
+	for _, item := range items {
+		// Typed objects are returned by the API without their kind
+		if gvks, _, err := scheme.Scheme.ObjectKinds(item); err == nil && len(gvks) > 0 {
+			item.GetObjectKind().SetGroupVersionKind(gvks[0])
+		}
+		if accessor, err := meta.Accessor(item); err == nil {
+			accessor.SetManagedFields(nil)
+		}
+	}
+
+	data, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	})
+	if err != nil {
+		b.addError(name, err)
+		return
+	}
+	b.add(name, data)
+}
+
+// collect reads each artifact in the order of openfaas-diagnostics.sh.
+// Files for a function namespace are named after it, so that the default
+// openfaas-fn namespace keeps the script's file names.
+func (b *bundle) collect(ctx context.Context) {
+	core := b.snapshot.CoreNamespace
+
+	var deployments []appsv1.Deployment
+	if list, err := b.client.AppsV1().Deployments(core).List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("01-openfaas-core-deploy.txt", err)
+	} else {
+		deployments = list.Items
+		b.add("01-openfaas-core-deploy.txt", deploymentTable(list.Items))
+		b.addList("04-openfaas-deploy.yaml", []runtime.Object{list})
+	}
+
+	if list, err := b.client.CoreV1().ConfigMaps(core).List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("02-openfaas-configmaps.yaml", err)
+	} else {
+		b.addList("02-openfaas-configmaps.yaml", []runtime.Object{list})
+	}
+
+	// Functions may read their settings from ConfigMaps in their own
+	// namespace, which are needed to check the bundle with --from-dir
+	for _, namespace := range b.snapshot.FunctionNamespaces {
+		name := fmt.Sprintf("02-%s-configmaps.yaml", namespace)
+		if list, err := b.client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{}); err != nil {
+			b.addError(name, err)
+		} else {
+			b.addList(name, []runtime.Object{list})
+		}
+	}
+
+	// Function custom resources are only created in operator mode
+	if b.snapshot.Components.Controller.Mode == "operator" && b.dynamic != nil {
+		var lists []runtime.Object
+		for _, namespace := range b.snapshot.FunctionNamespaces {
+			list, err := b.dynamic.Resource(checker.FunctionsResource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				b.addError("03-openfaas-function-crd.yaml", err)
+				continue
+			}
+			lists = append(lists, list)
+		}
+		b.addList("03-openfaas-function-crd.yaml", lists)
+	}
+
+	for _, namespace := range b.snapshot.FunctionNamespaces {
+		name := fmt.Sprintf("05-%s-deploy.yaml", namespace)
+		if list, err := b.client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{}); err != nil {
+			b.addError(name, err)
+		} else {
+			b.addList(name, []runtime.Object{list})
+		}
+	}
+
+	// The gateway is found by its containers, so its Deployment may have
+	// another name
+	gateway := b.snapshot.Components.Gateway.Deployment
+	if len(gateway) == 0 {
+		gateway = "gateway"
+	}
+
+	// The controller runs in the gateway's Pod as either the operator or
+	// faas-netes, only the container which is deployed is read
+	if mode := b.snapshot.Components.Controller.Mode; len(mode) > 0 {
+		b.addLogs(ctx, fmt.Sprintf("06-%s-logs.txt", mode), deployments, gateway, mode)
+	}
+	b.addLogs(ctx, "07-gateway-logs.txt", deployments, gateway, "gateway")
+
+	b.addEvents(ctx, "08-openfaas-events.txt", core)
+	for _, namespace := range b.snapshot.FunctionNamespaces {
+		b.addEvents(ctx, fmt.Sprintf("09-%s-events.txt", namespace), namespace)
+	}
+
+	namespaces := append([]string{core}, b.snapshot.FunctionNamespaces...)
+
+	var roles []runtime.Object
+	var roleRows [][]string
+	for _, namespace := range namespaces {
+		list, err := b.client.RbacV1().Roles(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			b.addError("11-role.yaml", err)
+			continue
+		}
+		roles = append(roles, list)
+		for _, role := range list.Items {
+			roleRows = append(roleRows, []string{role.Namespace, role.Name, createdAt(role.ObjectMeta)})
+		}
+	}
+	if len(roles) > 0 {
+		b.add("10-role-list.txt", table([]string{"NAMESPACE", "NAME", "CREATED AT"}, roleRows))
+		b.addList("11-role.yaml", roles)
+	}
+
+	if list, err := b.client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("13-clusterrole.yaml", err)
+	} else {
+		var rows [][]string
+		for _, role := range list.Items {
+			rows = append(rows, []string{role.Name, createdAt(role.ObjectMeta)})
+		}
+		b.add("12-clusterrole-list.txt", table([]string{"NAME", "CREATED AT"}, rows))
+		b.addList("13-clusterrole.yaml", []runtime.Object{list})
+	}
+
+	if list, err := b.client.CoreV1().ServiceAccounts(core).List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("15-serviceaccount.yaml", err)
+	} else {
+		var rows [][]string
+		for _, sa := range list.Items {
+			rows = append(rows, []string{sa.Name, createdAt(sa.ObjectMeta)})
+		}
+		b.add("14-serviceaccount-list.txt", table([]string{"NAME", "CREATED AT"}, rows))
+		b.addList("15-serviceaccount.yaml", []runtime.Object{list})
+	}
+
+	var bindings []runtime.Object
+	var bindingRows [][]string
+	for _, namespace := range namespaces {
+		list, err := b.client.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			b.addError("17-rolebinding.yaml", err)
+			continue
+		}
+		bindings = append(bindings, list)
+		for _, binding := range list.Items {
+			bindingRows = append(bindingRows, []string{binding.Namespace, binding.Name, binding.RoleRef.Kind + "/" + binding.RoleRef.Name, createdAt(binding.ObjectMeta)})
+		}
+	}
+	if len(bindings) > 0 {
+		b.add("16-rolebinding-list.txt", table([]string{"NAMESPACE", "NAME", "ROLE", "CREATED AT"}, bindingRows))
+		b.addList("17-rolebinding.yaml", bindings)
+	}
+
+	if list, err := b.client.PolicyV1().PodDisruptionBudgets(core).List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError(pdbFile, err)
+	} else {
+		b.addList(pdbFile, []runtime.Object{list})
+	}
+
+	if list, err := b.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("21-nodes.yaml", err)
+	} else {
+		b.addList("21-nodes.yaml", []runtime.Object{list})
+	}
+
+	if list, err := b.client.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("22-clusterrolebinding.yaml", err)
+	} else {
+		b.addList("22-clusterrolebinding.yaml", []runtime.Object{list})
+	}
+
+	if list, err := b.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("23-namespaces.yaml", err)
+	} else {
+		b.addList("23-namespaces.yaml", []runtime.Object{list})
+	}
+
+	// Pods are exported so that their health can be checked again
+	b.addPods(ctx, "24-openfaas-pods.yaml", core)
+	for _, namespace := range b.snapshot.FunctionNamespaces {
+		b.addPods(ctx, fmt.Sprintf("25-%s-pods.yaml", namespace), namespace)
+	}
+}
+
+func (b *bundle) addPods(ctx context.Context, name, namespace string) {
+	list, err := b.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.addError(name, err)
+		return
+	}
+	b.addList(name, []runtime.Object{list})
+}
+
+// addLogs reads the last lines of a container's logs from each Pod of a
+// core Deployment
+func (b *bundle) addLogs(ctx context.Context, name string, deployments []appsv1.Deployment, deployment, container string) {
+	var dep *appsv1.Deployment
+	for i := range deployments {
+		if deployments[i].Name == deployment {
+			dep = &deployments[i]
+		}
+	}
+	if dep == nil {
+		b.addError(name, fmt.Errorf("deployment %s not found in %s", deployment, b.snapshot.CoreNamespace))
+		return
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(dep.Spec.Selector)
+	if err != nil {
+		b.addError(name, err)
+		return
+	}
+
+	pods, err := b.client.CoreV1().Pods(dep.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		b.addError(name, err)
+		return
+	}
+
+	var out bytes.Buffer
+	for _, pod := range pods.Items {
+		opts := &corev1.PodLogOptions{Container: container}
+		if b.logLines > 0 {
+			opts.TailLines = &b.logLines
+		}
+
+		logs, err := b.client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+		if err != nil {
+			b.addError(name, fmt.Errorf("pod %s: %w", pod.Name, err))
+			continue
+		}
+		fmt.Fprintf(&out, "==> %s/%s <==\n", pod.Name, container)
+		out.Write(logs)
+		if len(logs) > 0 && logs[len(logs)-1] != '\n' {
+			out.WriteString("\n")
+		}
+	}
+	b.add(name, out.Bytes())
+}
+
+// addEvents writes the events of a namespace in the order they were
+// created, as with kubectl get events --sort-by=.metadata.creationTimestamp
+func (b *bundle) addEvents(ctx context.Context, name, namespace string) {
+	list, err := b.client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.addError(name, err)
+		return
+	}
+
+	events := list.Items
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreationTimestamp.Before(&events[j].CreationTimestamp)
+	})
+
+	var rows [][]string
+	for _, event := range events {
+		object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+		rows = append(rows, []string{createdAt(event.ObjectMeta), event.Type, event.Reason, object, event.Message})
+	}
+	b.add(name, table([]string{"CREATED AT", "TYPE", "REASON", "OBJECT", "MESSAGE"}, rows))
+}
+
+// dir is the directory of the bundle within the archive, which is named
+// after the installation's namespace
+func (b *bundle) dir() string {
+	return b.snapshot.CoreNamespace
+}
+
+// writeBundles writes the bundles as a gzipped tar, each within its own
+// directory so that it can be extracted and checked with --from-dir
+func writeBundles(w io.Writer, bundles []*bundle, modTime time.Time) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, b := range bundles {
+		files := b.files
+		if len(b.errors) > 0 {
+			files = append(files, bundleFile{Name: "collect-errors.txt", Data: []byte(strings.Join(b.errors, "\n") + "\n")})
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     b.dir() + "/",
+			Mode:     0755,
+			ModTime:  modTime,
+		}); err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     b.dir() + "/" + file.Name,
+				Mode:     0644,
+				Size:     int64(len(file.Data)),
+				ModTime:  modTime,
+			}); err != nil {
+				return err
+			}
+			if _, err := tw.Write(file.Data); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func deploymentTable(deployments []appsv1.Deployment) []byte {
+	var rows [][]string
+	for _, dep := range deployments {
+		var images []string
+		for _, container := range dep.Spec.Template.Spec.Containers {
+			images = append(images, container.Image)
+		}
+
+		desired := int32(1)
+		if dep.Spec.Replicas != nil {
+			desired = *dep.Spec.Replicas
+		}
+
+		rows = append(rows, []string{
+			dep.Name,
+			fmt.Sprintf("%d/%d", dep.Status.ReadyReplicas, desired),
+			fmt.Sprint(dep.Status.UpdatedReplicas),
+			fmt.Sprint(dep.Status.AvailableReplicas),
+			strings.Join(images, ","),
+		})
+	}
+	return table([]string{"NAME", "READY", "UP-TO-DATE", "AVAILABLE", "IMAGES"}, rows)
+}
+
+func table(header []string, rows [][]string) []byte {
+	var out bytes.Buffer
+	w := tabwriter.NewWriter(&out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return out.Bytes()
+}
+
+func createdAt(objectMeta metav1.ObjectMeta) string {
+	return objectMeta.CreationTimestamp.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"config-checker/pkg/checker"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_bundle_Collect(t *testing.T) {
+	gateway := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "openfaas", Labels: map[string]string{"app": "openfaas"}},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "gateway"}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "gateway", Image: "ghcr.io/openfaas/gateway:0.25.2"},
+						{Name: "faas-netes", Image: "ghcr.io/openfaas/faas-netes:0.16.2"},
+					},
+				},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway-abc", Namespace: "openfaas", Labels: map[string]string{"app": "gateway"}},
+	}
+	fn := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "staging-fn"},
+	}
+
+	clientset := fake.NewSimpleClientset(gateway, pod, fn)
+	clientset.PrependReactor("list", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewForbidden(schema.GroupResource{Resource: "events"}, "", nil)
+	})
+
+	snapshot := &checker.ClusterSnapshot{
+		CoreNamespace:      "openfaas",
+		FunctionNamespaces: []string{"staging-fn"},
+		Components: checker.Components{
+			Controller: checker.Controller{Mode: "faas-netes"},
+		},
+	}
+
+	b := newBundle(clientset, nil, snapshot)
+	b.collect(context.Background())
+
+	var archive bytes.Buffer
+	if err := writeBundles(&archive, []*bundle{b}, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	files := readArchive(t, &archive)
+
+	for _, name := range []string{
+		"openfaas/01-openfaas-core-deploy.txt",
+		"openfaas/04-openfaas-deploy.yaml",
+		"openfaas/05-staging-fn-deploy.yaml",
+		"openfaas/06-faas-netes-logs.txt",
+		"openfaas/07-gateway-logs.txt",
+		"openfaas/20-openfaas-pdb.yaml",
+		"openfaas/21-nodes.yaml",
+		"openfaas/22-clusterrolebinding.yaml",
+		"openfaas/23-namespaces.yaml",
+		"openfaas/collect-errors.txt",
+	} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("want %s in the bundle", name)
+		}
+	}
+
+	if _, ok := files["openfaas/06-operator-logs.txt"]; ok {
+		t.Errorf("want no operator logs in faas-netes mode")
+	}
+
+	if !strings.Contains(files["openfaas/07-gateway-logs.txt"], "==> gateway-abc/gateway <==") {
+		t.Errorf("want the logs of the gateway Pod, got: %q", files["openfaas/07-gateway-logs.txt"])
+	}
+
+	if got := strings.Count(files["openfaas/collect-errors.txt"], "forbidden"); got != 2 {
+		t.Errorf("want the events of both namespaces to be recorded as errors, got:\n%s", files["openfaas/collect-errors.txt"])
+	}
+
+	// The exported Deployments should be readable by --from-dir
+	objects, _, err := decodeObjects(strings.NewReader(files["openfaas/05-staging-fn-deploy.yaml"]))
+	if err != nil {
+		t.Fatalf("unexpected error decoding the bundle: %s", err)
+	}
+	if len(objects) != 1 {
+		t.Errorf("want 1 function Deployment, got %d", len(objects))
+	}
+}
+
+func readArchive(t *testing.T, r io.Reader) map[string]string {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("bundle is not gzipped: %s", err)
+	}
+
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("bundle is not a valid tar: %s", err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = string(data)
+	}
+	return files
+}
+
+func Test_writeBundles_OneDirectoryPerInstallation(t *testing.T) {
+	var bundles []*bundle
+	for _, namespace := range []string{"openfaas", "team-b"} {
+		b := newBundle(fake.NewSimpleClientset(), nil, &checker.ClusterSnapshot{CoreNamespace: namespace})
+		b.add("18-checker-report.txt", []byte(namespace))
+		bundles = append(bundles, b)
+	}
+	bundles[1].addError("04-openfaas-deploy.yaml", fmt.Errorf("forbidden"))
+
+	var archive bytes.Buffer
+	if err := writeBundles(&archive, bundles, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	files := readArchive(t, &archive)
+	for name, want := range map[string]string{
+		"openfaas/18-checker-report.txt": "openfaas",
+		"team-b/18-checker-report.txt":   "team-b",
+		"team-b/collect-errors.txt":      "04-openfaas-deploy.yaml: forbidden\n",
+	} {
+		if files[name] != want {
+			t.Errorf("want %s to be %q, got %q", name, want, files[name])
+		}
+	}
+	if _, ok := files["openfaas/collect-errors.txt"]; ok {
+		t.Errorf("want no errors for openfaas")
+	}
+}
+
+func Test_bundle_CheckedAgainWithFromDir(t *testing.T) {
+	labels := func(key, value string) map[string]string {
+		return map[string]string{key: value}
+	}
+
+	gateway := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "openfaas", Labels: labels("app", "openfaas")},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels("app", "gateway")},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels("app", "gateway")},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: "openfaas-controller",
+					Containers: []corev1.Container{
+						{Name: "gateway", Image: "ghcr.io/openfaas/gateway:0.25.2"},
+						{Name: "faas-netes", Image: "ghcr.io/openfaas/faas-netes:0.16.2"},
+					},
+				},
+			},
+		},
+	}
+
+	// The function's timeouts are only found in its ConfigMap
+	fn := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "openfaas-fn", Labels: labels("faas_function", "env")},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels("faas_function", "env")},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels("faas_function", "env")},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "env",
+						Image: "ghcr.io/openfaas/alpine:latest",
+						EnvFrom: []corev1.EnvFromSource{{
+							ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "env-timeouts"}},
+						}},
+					}},
+				},
+			},
+		},
+	}
+	timeouts := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "env-timeouts", Namespace: "openfaas-fn"},
+		Data:       map[string]string{"read_timeout": "10s", "write_timeout": "10s", "exec_timeout": "10s"},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "env-abc", Namespace: "openfaas-fn", Labels: labels("faas_function", "env")},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "env",
+				RestartCount: 20,
+				State: corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+				},
+			}},
+		},
+	}
+
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "openfaas"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "openfaas-fn"}},
+		gateway, fn, timeouts, pod,
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "openfaas-controller"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"create"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "openfaas-controller"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "openfaas-controller"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "openfaas-controller", Namespace: "openfaas"},
+			},
+		},
+	)
+
+	ctx := context.Background()
+	config := checker.DefaultConfig()
+
+	snapshot, err := checker.NewCollector(clientset, "openfaas").Collect(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := checker.Analyze(snapshot, config)
+
+	b := newBundle(clientset, nil, snapshot)
+	b.collect(ctx)
+
+	var archive bytes.Buffer
+	if err := writeBundles(&archive, []*bundle{b}, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dir := t.TempDir()
+	for name, data := range readArchive(t, &archive) {
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(name)), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	offlineClient, dynamicClient, err := getOfflineClientset(dir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	offline, err := checker.NewCollector(offlineClient, "openfaas").
+		WithDynamicClient(dynamicClient).
+		Collect(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := checker.Analyze(offline, config)
+
+	messages := func(findings []checker.Finding) []string {
+		var out []string
+		for _, f := range findings {
+			out = append(out, fmt.Sprintf("[%s] %s", f.RuleID, f.Message))
+		}
+		sort.Strings(out)
+		return out
+	}
+	if !reflect.DeepEqual(messages(got), messages(want)) {
+		t.Errorf("want the same findings from the bundle as from the cluster\nwant:\n%s\ngot:\n%s",
+			strings.Join(messages(want), "\n"), strings.Join(messages(got), "\n"))
+	}
+
+	for _, f := range want {
+		if f.RuleID == "OF-CHK-001" || strings.HasPrefix(f.RuleID, "OF-NS-") {
+			t.Errorf("want the function's timeouts and bindings to be read, got: %s", f.Message)
+		}
+	}
+	if !strings.Contains(strings.Join(messages(want), "\n"), "[OF-POD-") {
+		t.Errorf("want the function's Pod to be reported, got: %v", messages(want))
+	}
+}
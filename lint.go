@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"config-checker/pkg/checker"
+)
+
+// LintReport is the result of checking the functions in a stack.yml
+// before they are deployed
+type LintReport struct {
+	File               string                        `json:"file"`
+	FunctionNamespaces []string                      `json:"functionNamespaces"`
+	Functions          map[string][]checker.Function `json:"functions"`
+	Warnings           []checker.Finding             `json:"warnings"`
+
+	// Suppressed are findings for rules ignored through annotations
+	Suppressed []checker.Finding `json:"suppressed"`
+}
+
+// runLint runs the function rules against a faas-cli stack.yml, for the
+// lint subcommand
+func runLint(args []string) {
+	var (
+		stackFile       string
+		upstreamTimeout string
+		output          string
+		failOn          string
+		configFile      string
+		redact          bool
+	)
+
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	flags.StringVar(&stackFile, "f", "stack.yml", "Path to the faas-cli stack.yml")
+	flags.StringVar(&upstreamTimeout, "gateway-upstream-timeout", "", "The gateway's upstream_timeout to check function timeouts against e.g. 60s, when not set they are not compared")
+	flags.StringVar(&output, "output", "text", "Output format for the report: text or json")
+	flags.StringVar(&failOn, "fail-on", "", "Exit with a non-zero code when there are findings of this severity or higher: warning or error")
+	flags.StringVar(&configFile, "config", "", "Path to a YAML file to override the thresholds used by the rules")
+	flags.BoolVar(&redact, "redact", false, "Replace the names of functions, namespaces and private registries with hashes, to share the report")
+	flags.Parse(args)
+
+	checkOutput(output)
+	failOnSeverity := parseFailOn(failOn)
+	config := loadConfig(configFile)
+
+	snapshot, err := checker.ReadStack(stackFile)
+	if err != nil {
+		log.Fatalf("Error reading %s: %s", stackFile, err)
+	}
+
+	if len(upstreamTimeout) > 0 {
+		snapshot.Components.Gateway.Timeout.Additional["upstream_timeout"] = upstreamTimeout
+		if _, err := snapshot.Components.Gateway.Timeout.GetAdditionalTimeout("upstream_timeout"); err != nil {
+			log.Fatalf("Invalid value for --gateway-upstream-timeout: %s", err)
+		}
+	}
+
+	findings := checker.AnalyzeFunctions(snapshot, config)
+	redactReport(newRedactor(redact), snapshot, findings)
+
+	report := LintReport{
+		File:               stackFile,
+		FunctionNamespaces: snapshot.FunctionNamespaces,
+		Functions:          snapshot.Functions,
+	}
+	report.Warnings, report.Suppressed = splitSuppressed(findings)
+
+	if output == "json" {
+		if err := writeJSONReport(os.Stdout, report); err != nil {
+			log.Fatalf("Error writing report: %s", err)
+		}
+	} else {
+		writeLintReport(os.Stdout, report)
+	}
+
+	exitOnFindings(findings, failOnSeverity)
+}
+
+func writeLintReport(w io.Writer, report LintReport) {
+	total := 0
+	for _, namespace := range report.FunctionNamespaces {
+		total += len(report.Functions[namespace])
+	}
+
+	fmt.Fprintf(w, "Linted %d function(s) in %s\n", total, report.File)
+
+	fmt.Fprintf(w, "\nWarnings:\n\n")
+	if len(report.Warnings) == 0 {
+		fmt.Fprintf(w, "None\n")
+	}
+
+	writeFindings(w, report.Warnings, report.Suppressed)
+}
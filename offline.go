@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"config-checker/pkg/checker"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// offlineVersion is reported as the Kubernetes version when
+// running against exported files instead of a live cluster.
+const offlineVersion = "<offline>"
+
+// fileList is a flag which can be given more than once
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// getOfflineClientset returns a clientset backed by the Deployments,
+// Namespaces, ConfigMaps, Pods, PodDisruptionBudgets, Nodes, roles and
+// bindings found in exported YAML or JSON files, such as those produced
+// by openfaas-diagnostics.sh, so that the checks can be run without access
+// to the cluster. Function custom resources are served by the dynamic client.
+func getOfflineClientset(dir string, files []string) (kubernetes.Interface, dynamic.Interface, error) {
+	paths := []string{}
+	if len(dir) > 0 {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read directory %s: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+				paths = append(paths, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	paths = append(paths, files...)
+
+	var objects, functions []runtime.Object
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to open %s: %w", path, err)
+		}
+
+		objs, fns, err := decodeObjects(f)
+		f.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to decode %s: %w", path, err)
+		}
+		objects = append(objects, objs...)
+		functions = append(functions, fns...)
+	}
+
+	objects = addMissingNamespaces(uniqueObjects(objects))
+
+	clientset := fake.NewSimpleClientset(objects...)
+	if discovery, ok := clientset.Discovery().(*fakediscovery.FakeDiscovery); ok {
+		discovery.FakedServerVersion = &version.Info{GitVersion: offlineVersion}
+	}
+
+	// Without any exported PodDisruptionBudgets or bindings, the core
+	// components would be reported as not having a budget and the
+	// controller as not having access to its namespaces, so they are
+	// treated as not served. A bundle from collect always has pdbFile
+	// when the budgets could be listed, so when it is empty there are none.
+	if !hasObjects(objects, &policyv1.PodDisruptionBudget{}) && !hasFile(paths, pdbFile) {
+		notServed(clientset, policyv1.Resource("poddisruptionbudgets"))
+	}
+	if !hasObjects(objects, &rbacv1.RoleBinding{}) {
+		notServed(clientset, rbacv1.Resource("rolebindings"))
+	}
+	if !hasObjects(objects, &rbacv1.ClusterRoleBinding{}) {
+		notServed(clientset, rbacv1.Resource("clusterrolebindings"))
+	}
+
+	// Without any exported Function custom resources, every function would
+	// be reported as not having one, so they are not read at all.
+	if len(functions) == 0 {
+		return clientset, nil, nil
+	}
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			checker.FunctionsResource: "FunctionList",
+		},
+		uniqueObjects(functions)...)
+
+	return clientset, dynamicClient, nil
+}
+
+// decodeObjects reads every supported object, such as a Deployment, from a
+// stream of YAML documents or JSON, including items within a List.
+// Function custom resources are returned separately as unstructured
+// objects, and other kinds such as other custom resources are skipped.
+func decodeObjects(r io.Reader) ([]runtime.Object, []runtime.Object, error) {
+	decoder := scheme.Codecs.UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+
+	var objects, functions []runtime.Object
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			if runtime.IsNotRegisteredError(err) || runtime.IsMissingKind(err) {
+				if fn, ok := decodeFunction(doc); ok {
+					functions = append(functions, fn)
+				}
+				continue
+			}
+			return nil, nil, err
+		}
+
+		if list, ok := obj.(*corev1.List); ok {
+			for _, item := range list.Items {
+				itemObj, _, err := decoder.Decode(item.Raw, nil, nil)
+				if err != nil {
+					if runtime.IsNotRegisteredError(err) || runtime.IsMissingKind(err) {
+						if fn, ok := decodeFunction(item.Raw); ok {
+							functions = append(functions, fn)
+						}
+						continue
+					}
+					return nil, nil, err
+				}
+				if isSupportedObject(itemObj) {
+					objects = append(objects, itemObj)
+				}
+			}
+			continue
+		}
+
+		if isSupportedObject(obj) {
+			objects = append(objects, obj)
+		}
+	}
+
+	return objects, functions, nil
+}
+
+// decodeFunction returns the document as an unstructured object when it
+// is an OpenFaaS Function
+func decodeFunction(doc []byte) (runtime.Object, bool) {
+	data, err := utilyaml.ToJSON(doc)
+	if err != nil {
+		return nil, false
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(data); err != nil {
+		return nil, false
+	}
+
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != checker.FunctionsResource.Group || gvk.Kind != "Function" {
+		return nil, false
+	}
+	return obj, true
+}
+
+func isSupportedObject(obj runtime.Object) bool {
+	switch obj.(type) {
+	case *v1.Deployment, *corev1.Namespace, *corev1.ConfigMap, *corev1.Pod,
+		*policyv1.PodDisruptionBudget, *corev1.Node,
+		*rbacv1.Role, *rbacv1.RoleBinding, *rbacv1.ClusterRoleBinding, *rbacv1.ClusterRole:
+		return true
+	}
+	return false
+}
+
+// hasObjects is true when any of the objects is of the same type as kind
+func hasObjects(objects []runtime.Object, kind runtime.Object) bool {
+	for _, obj := range objects {
+		if reflect.TypeOf(obj) == reflect.TypeOf(kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFile is true when any of the paths is a file with the name
+func hasFile(paths []string, name string) bool {
+	for _, path := range paths {
+		if filepath.Base(path) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// notServed makes a list of the resource return not found, as if the API
+// was not served
+func notServed(clientset *fake.Clientset, resource schema.GroupResource) {
+	clientset.PrependReactor("list", resource.Resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(resource, "")
+	})
+}
+
+// uniqueObjects drops repeated objects, so that the same Deployment can
+// be found in more than one file without causing an error.
+func uniqueObjects(objects []runtime.Object) []runtime.Object {
+	seen := map[string]bool{}
+	var unique []runtime.Object
+	for _, obj := range objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		key := fmt.Sprintf("%T/%s/%s", obj, accessor.GetNamespace(), accessor.GetName())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, obj)
+	}
+	return unique
+}
+
+// addMissingNamespaces creates a Namespace for each namespace referenced
+// by a Deployment, since exported bundles rarely include the Namespaces
+// themselves.
+func addMissingNamespaces(objects []runtime.Object) []runtime.Object {
+	found := map[string]bool{}
+	for _, obj := range objects {
+		if ns, ok := obj.(*corev1.Namespace); ok {
+			found[ns.Name] = true
+		}
+	}
+
+	missing := []string{}
+	for _, obj := range objects {
+		if dep, ok := obj.(*v1.Deployment); ok {
+			if len(dep.Namespace) > 0 && !found[dep.Namespace] {
+				found[dep.Namespace] = true
+				missing = append(missing, dep.Namespace)
+			}
+		}
+	}
+	sort.Strings(missing)
+
+	for _, name := range missing {
+		objects = append(objects, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		})
+	}
+
+	return objects
+}
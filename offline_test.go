@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"config-checker/pkg/checker"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const exportedDeployments = `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: gateway
+    namespace: openfaas
+    labels:
+      app: openfaas
+  spec:
+    replicas: 1
+    selector:
+      matchLabels:
+        app: gateway
+    template:
+      metadata:
+        labels:
+          app: gateway
+      spec:
+        containers:
+        - name: gateway
+          image: ghcr.io/openfaas/gateway:0.25.2
+- apiVersion: openfaas.com/v1
+  kind: Function
+  metadata:
+    name: env
+    namespace: openfaas-fn
+  spec:
+    image: ghcr.io/openfaas/alpine:latest
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: openfaas
+`
+
+func Test_decodeObjects_SkipsCustomResources(t *testing.T) {
+	objects, functions, err := decodeObjects(strings.NewReader(exportedDeployments))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(objects) != 2 {
+		t.Fatalf("want the Deployment and ConfigMap, got %d objects", len(objects))
+	}
+
+	if len(functions) != 1 {
+		t.Fatalf("want the Function custom resource, got %d functions", len(functions))
+	}
+}
+
+func Test_getOfflineClientset_FromDir(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "04-openfaas-deploy.yaml"), []byte(exportedDeployments), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Duplicate files and plain-text output should be ignored
+	if err := os.WriteFile(filepath.Join(dir, "04-copy.yaml"), []byte(exportedDeployments), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "01-openfaas-core-deploy.txt"), []byte("NAME READY"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clientset, dynamicClient, err := getOfflineClientset(dir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx := context.Background()
+	deps, err := clientset.AppsV1().Deployments("openfaas").List(ctx, metav1.ListOptions{
+		LabelSelector: "app=openfaas",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps.Items) != 1 || deps.Items[0].Name != "gateway" {
+		t.Fatalf("want the gateway deployment, got %v", deps.Items)
+	}
+
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "openfaas", metav1.GetOptions{}); err != nil {
+		t.Fatalf("want namespace openfaas to be created, got: %s", err)
+	}
+
+	functions, err := dynamicClient.Resource(checker.FunctionsResource).Namespace("openfaas-fn").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions.Items) != 1 || functions.Items[0].GetName() != "env" {
+		t.Fatalf("want the env function, got %v", functions.Items)
+	}
+
+	ver, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ver.String() != offlineVersion {
+		t.Fatalf("want version %s, got %s", offlineVersion, ver.String())
+	}
+}
+
+func Test_getOfflineClientset_WithoutPodDisruptionBudgets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "04-openfaas-deploy.yaml"), []byte(exportedDeployments), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clientset, _, err := getOfflineClientset(dir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// A bundle without any budgets may not have exported them, so they are
+	// not found rather than an empty list
+	_, err = clientset.PolicyV1().PodDisruptionBudgets("openfaas").List(context.Background(), metav1.ListOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("want a not found error, got: %v", err)
+	}
+
+	pdb := `apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: gateway
+  namespace: openfaas
+spec:
+  minAvailable: 1
+  selector:
+    matchLabels:
+      app: gateway
+`
+	if err := os.WriteFile(filepath.Join(dir, "20-openfaas-pdb.yaml"), []byte(pdb), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clientset, _, err = getOfflineClientset(dir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	budgets, err := clientset.PolicyV1().PodDisruptionBudgets("openfaas").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(budgets.Items) != 1 || budgets.Items[0].Name != "gateway" {
+		t.Fatalf("want the gateway budget, got %v", budgets.Items)
+	}
+}
+
+// A bundle from collect has an empty list when there are no budgets, so
+// OF-HA-001 is reported as it is for the cluster
+func Test_getOfflineClientset_EmptyPodDisruptionBudgets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "04-openfaas-deploy.yaml"), []byte(exportedDeployments), 0600); err != nil {
+		t.Fatal(err)
+	}
+	empty := `apiVersion: v1
+items: []
+kind: List
+`
+	if err := os.WriteFile(filepath.Join(dir, pdbFile), []byte(empty), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clientset, _, err := getOfflineClientset(dir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	budgets, err := clientset.PolicyV1().PodDisruptionBudgets("openfaas").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("want an empty list, got: %s", err)
+	}
+	if len(budgets.Items) != 0 {
+		t.Fatalf("want no budgets, got %v", budgets.Items)
+	}
+}
@@ -1,5 +1,8 @@
 #!/bin/bash
 
+# Superseded by "config-checker collect", which reads every function
+# namespace and the report from the checker.
+
 mkdir -p ./openfaas
 
 echo "Gathering diagnostics to: ./openfaas"
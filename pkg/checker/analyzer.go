@@ -0,0 +1,150 @@
+package checker
+
+import (
+	"sort"
+	"time"
+)
+
+// Finding is a problem or recommendation found by a Rule. Namespace and
+// Function are set when the finding applies to a namespace or to a
+// single function.
+type Finding struct {
+	RuleID    string   `json:"ruleId"`
+	Severity  Severity `json:"severity"`
+	Category  Category `json:"category"`
+	Message   string   `json:"message"`
+	Namespace string   `json:"namespace,omitempty"`
+	Function  string   `json:"function,omitempty"`
+	Docs      string   `json:"docs,omitempty"`
+
+	// Suppressed is true when the rule is ignored with IgnoreAnnotation
+	// on the function's Deployment or its namespace
+	Suppressed bool `json:"suppressed,omitempty"`
+}
+
+// Analyze runs the registered rules against a snapshot using the
+// thresholds from config. Findings for rules which are ignored through
+// IgnoreAnnotation are returned with Suppressed set. Settings which can
+// not be parsed are reported as findings and skipped by the other rules.
+func Analyze(snapshot *ClusterSnapshot, config Config) []Finding {
+	return append(AnalyzeCluster(snapshot, config), AnalyzeFunctions(snapshot, config)...)
+}
+
+// AnalyzeCluster runs only the rules for the core components, such as
+// when checking the chart's values before they are installed.
+func AnalyzeCluster(snapshot *ClusterSnapshot, config Config) []Finding {
+	t := config.Thresholds
+
+	var findings []Finding
+
+	for _, rule := range registry {
+		if rule.CheckCluster == nil {
+			continue
+		}
+		for _, message := range rule.CheckCluster(snapshot, t) {
+			finding := rule.finding(message)
+			finding.Suppressed = snapshot.ignored(snapshot.CoreNamespace, rule.ID)
+			findings = append(findings, finding)
+		}
+	}
+
+	for _, rule := range registry {
+		if rule.CheckClusterNamespaces == nil {
+			continue
+		}
+		messages := rule.CheckClusterNamespaces(snapshot, t)
+		namespaces := make([]string, 0, len(messages))
+		for namespace := range messages {
+			namespaces = append(namespaces, namespace)
+		}
+		sort.Strings(namespaces)
+
+		// The rule can be ignored by the namespace the finding is about,
+		// or for every namespace by the core namespace
+		for _, namespace := range namespaces {
+			finding := rule.finding(messages[namespace])
+			finding.Namespace = namespace
+			finding.Suppressed = snapshot.ignored(snapshot.CoreNamespace, rule.ID) || snapshot.ignored(namespace, rule.ID)
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+// AnalyzeFunctions runs only the function and namespace rules, such as
+// when checking functions before they are deployed, where the core
+// components are not known.
+func AnalyzeFunctions(snapshot *ClusterSnapshot, config Config) []Finding {
+	t := config.Thresholds
+
+	var findings []Finding
+
+	for _, namespace := range snapshot.FunctionNamespaces {
+		functions, ok := snapshot.Functions[namespace]
+		if !ok {
+			continue
+		}
+
+		for _, fn := range functions {
+			for _, rule := range registry {
+				if rule.CheckFunction == nil {
+					continue
+				}
+				for _, message := range rule.CheckFunction(snapshot, t, namespace, fn) {
+					finding := rule.finding(message)
+					finding.Namespace = namespace
+					finding.Function = fn.Name
+					finding.Suppressed = snapshot.ignored(namespace, rule.ID) || fn.ignored(rule.ID)
+					findings = append(findings, finding)
+				}
+			}
+		}
+
+		for _, rule := range registry {
+			if rule.CheckNamespace == nil {
+				continue
+			}
+			messages := rule.CheckNamespace(snapshot, t, namespace, functions)
+			if len(messages) == 0 {
+				continue
+			}
+
+			// When the rule still applies to the functions which do not
+			// ignore it, then the finding is not suppressed.
+			suppressed := snapshot.ignored(namespace, rule.ID) ||
+				len(rule.CheckNamespace(snapshot, t, namespace, notIgnoring(functions, rule.ID))) == 0
+
+			for _, message := range messages {
+				finding := rule.finding(message)
+				finding.Namespace = namespace
+				finding.Suppressed = suppressed
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	return findings
+}
+
+// CountAtLeast returns the number of findings which are as serious as, or
+// more serious than min, suppressed findings are not counted.
+func CountAtLeast(findings []Finding, min Severity) int {
+	count := 0
+	for _, finding := range findings {
+		if !finding.Suppressed && finding.Severity.AtLeast(min) {
+			count++
+		}
+	}
+	return count
+}
+
+// gatewayUpstreamTimeout returns false when the gateway's upstream_timeout
+// is not set or can not be parsed, which is reported by OF-CHK-002
+func (s *ClusterSnapshot) gatewayUpstreamTimeout() (time.Duration, bool) {
+	if s.Components.Gateway.Timeout == nil {
+		return 0, false
+	}
+	d, err := s.Components.Gateway.Timeout.GetAdditionalTimeout("upstream_timeout")
+	return d, err == nil
+}
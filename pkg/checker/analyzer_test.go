@@ -0,0 +1,583 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+func newSnapshot() *ClusterSnapshot {
+	gatewayTimeout := NewTimeout()
+	gatewayTimeout.Additional["upstream_timeout"] = "60s"
+
+	return &ClusterSnapshot{
+		CoreNamespace: "openfaas",
+		Components: Components{
+			Gateway: Gateway{
+				Replicas: 3,
+				Timeout:  gatewayTimeout,
+			},
+			Controller: Controller{
+				Mode:           "operator",
+				Timeout:        NewTimeout(),
+				SetNonRootUser: true,
+				ClusterRole:    true,
+			},
+		},
+		FunctionNamespaces: []string{"openfaas-fn"},
+		Functions:          map[string][]Function{},
+	}
+}
+
+func newFunction(name string) Function {
+	timeout := NewTimeout()
+	timeout.ReadTimeout = "10s"
+	timeout.WriteTimeout = "10s"
+	timeout.Additional["exec_timeout"] = "10s"
+
+	// The probes which faas-netes adds by default
+	probe := func() *Probe {
+		return &Probe{Type: "http", Path: "/_/health", InitialDelaySeconds: 2, PeriodSeconds: 2, TimeoutSeconds: 1, FailureThreshold: 3}
+	}
+
+	return Function{
+		Name:                   name,
+		Replicas:               1,
+		Timeout:                timeout,
+		Scaling:                &Scaling{Zero: "true", ZeroDuration: "15m"},
+		Requests:               &FunctionResources{Memory: "20Mi", CPU: "0"},
+		Limits:                 &FunctionResources{Memory: "128Mi", CPU: "0"},
+		ReadOnlyRootFilesystem: true,
+		Probes:                 &Probes{Liveness: probe(), Readiness: probe()},
+		PodSecurity:            &PodSecurity{Level: PodSecurityRestricted},
+	}
+}
+
+func hasFinding(findings []Finding, ruleID, substr string) bool {
+	for _, f := range findings {
+		if f.RuleID == ruleID && strings.Contains(f.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_Analyze_GatewayReplicas(t *testing.T) {
+	snapshot := newSnapshot()
+	snapshot.Components.Gateway.Replicas = 1
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	if !hasFinding(findings, "OF-GW-001", "gateway replicas want >= 3 but got 1") {
+		t.Errorf("want a finding for gateway replicas, got: %v", findings)
+	}
+}
+
+func Test_Analyze_JetStreamUpstreamTimeoutMismatch(t *testing.T) {
+	snapshot := newSnapshot()
+	queueWorkerTimeout := NewTimeout()
+	queueWorkerTimeout.Additional["upstream_timeout"] = "30s"
+	snapshot.Components.QueueWorker = &QueueWorker{
+		Replicas:    3,
+		MaxInflight: 50,
+		AckWait:     "30s",
+		Timeout:     queueWorkerTimeout,
+		JetStream:   true,
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	if !hasFinding(findings, "OF-QW-002", "queue-worker upstream_timeout (30s) must be equal to gateway.upstream_timeout (1m0s)") {
+		t.Errorf("want a finding for upstream_timeout, got: %v", findings)
+	}
+	if hasFinding(findings, "OF-QW-004", "") || hasFinding(findings, "OF-QW-009", "") {
+		t.Errorf("want no NATS Streaming finding when using JetStream, got: %v", findings)
+	}
+}
+
+func Test_Analyze_FunctionExecTimeout(t *testing.T) {
+	snapshot := newSnapshot()
+	fn := newFunction("env")
+	fn.Timeout.Additional["exec_timeout"] = "2m"
+	snapshot.Functions["openfaas-fn"] = []Function{fn}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	found := false
+	for _, f := range findings {
+		if f.RuleID == "OF-FN-005" && f.Severity == SeverityError &&
+			f.Function == "env" && f.Namespace == "openfaas-fn" &&
+			strings.Contains(f.Message, "exec_timeout (2m0s) is greater than gateway.upstream_timeout (1m0s)") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("want a finding for exec_timeout on env.openfaas-fn, got: %v", findings)
+	}
+}
+
+func Test_Analyze_SuppressedByFunction(t *testing.T) {
+	snapshot := newSnapshot()
+	fn := newFunction("env")
+	fn.Timeout.ReadTimeout = ""
+	fn.IgnoredRules = []string{"OF-FN-001"}
+	snapshot.Functions["openfaas-fn"] = []Function{fn}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	for _, f := range findings {
+		if f.RuleID == "OF-FN-001" && !f.Suppressed {
+			t.Errorf("want OF-FN-001 to be suppressed")
+		}
+	}
+
+	if got := CountAtLeast(findings, SeverityWarning); got != 0 {
+		t.Errorf("want suppressed findings not to be counted, got %d", got)
+	}
+}
+
+func Test_Analyze_SuppressedNamespaceRule(t *testing.T) {
+	snapshot := newSnapshot()
+	batch := newFunction("batch")
+	batch.ReadOnlyRootFilesystem = false
+	batch.IgnoredRules = []string{"OF-FN-007"}
+	snapshot.Functions["openfaas-fn"] = []Function{newFunction("env"), batch}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	for _, f := range findings {
+		if f.RuleID == "OF-FN-007" && !f.Suppressed {
+			t.Errorf("want OF-FN-007 to be suppressed when only batch ignores it")
+		}
+	}
+
+	other := newFunction("other")
+	other.ReadOnlyRootFilesystem = false
+	snapshot.Functions["openfaas-fn"] = append(snapshot.Functions["openfaas-fn"], other)
+
+	findings = Analyze(snapshot, DefaultConfig())
+
+	for _, f := range findings {
+		if f.RuleID == "OF-FN-007" && f.Suppressed {
+			t.Errorf("want OF-FN-007 to be reported for other")
+		}
+	}
+}
+
+func Test_Analyze_SuppressedByNamespace(t *testing.T) {
+	snapshot := newSnapshot()
+	snapshot.Components.Gateway.Replicas = 1
+	snapshot.IgnoredRules = map[string][]string{
+		"openfaas": {"OF-GW-001"},
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	for _, f := range findings {
+		if f.RuleID == "OF-GW-001" && !f.Suppressed {
+			t.Errorf("want OF-GW-001 to be suppressed by the core namespace")
+		}
+	}
+}
+
+func Test_Analyze_Thresholds(t *testing.T) {
+	snapshot := newSnapshot()
+	snapshot.Components.Gateway.Replicas = 1
+
+	config := DefaultConfig()
+	config.Thresholds.GatewayReplicas = 1
+
+	findings := Analyze(snapshot, config)
+
+	if hasFinding(findings, "OF-GW-001", "") {
+		t.Errorf("want no finding for 1 gateway replica when the threshold is 1, got: %v", findings)
+	}
+
+	// The HA verdict agrees with OF-GW-001, a single replica is on one node
+	// and has no PodDisruptionBudget
+	snapshot.HA = map[string]*HighAvailability{
+		"gateway": {PodDisruptionBudgets: []string{}, Running: 1, Nodes: 1},
+	}
+	if !snapshot.Features(config.Thresholds).HAGateway {
+		t.Errorf("want the gateway to be HA when the threshold is 1")
+	}
+	if snapshot.Features(DefaultConfig().Thresholds).HAGateway {
+		t.Errorf("want the gateway not to be HA with the default threshold")
+	}
+
+	// The "HA Gateway" feature uses the same threshold as OF-GW-001 where
+	// the Pods were not collected, such as for a values file
+	snapshot.HA = nil
+	snapshot.Components.Gateway.Replicas = 2
+	config.Thresholds.GatewayReplicas = 2
+
+	if hasFinding(Analyze(snapshot, config), "OF-GW-001", "") {
+		t.Errorf("want no finding for 2 gateway replicas when the threshold is 2")
+	}
+	if !snapshot.Features(config.Thresholds).HAGateway {
+		t.Errorf("want the gateway to be HA with 2 replicas when the threshold is 2")
+	}
+	if !hasFinding(Analyze(snapshot, DefaultConfig()), "OF-GW-001", "") {
+		t.Errorf("want a finding for 2 gateway replicas with the default threshold")
+	}
+	if snapshot.Features(DefaultConfig().Thresholds).HAGateway {
+		t.Errorf("want the gateway not to be HA with 2 replicas and the default threshold")
+	}
+}
+
+// Timeouts set from a Secret are not known, so the watchdog's default is
+// not assumed for them
+func Test_Analyze_UnresolvedTimeouts(t *testing.T) {
+	snapshot := newSnapshot()
+	snapshot.Components.Gateway.Image = "ghcr.io/openfaas/gateway:0.25.2"
+	snapshot.Components.Gateway.Timeout.set(envVar{Name: "upstream_timeout", Source: "secret/gateway:upstream", Unresolved: true})
+
+	fn := newFunction("env")
+	fn.Watchdog = WatchdogConfig{Watchdog: WatchdogOf, Mode: "http"}
+	fn.Timeout = NewTimeout()
+	fn.Timeout.set(envVar{Name: "exec_timeout", Value: "2m", Source: "env"})
+	fn.Timeout.set(envVar{Name: "read_timeout", Source: "secret/env:read", Unresolved: true})
+	fn.Timeout.set(envVar{Name: "write_timeout", Source: "secret/env:write", Unresolved: true})
+	snapshot.Functions["openfaas-fn"] = []Function{fn}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	for _, rule := range []string{"OF-FN-001", "OF-FN-002", "OF-FN-009"} {
+		if hasFinding(findings, rule, "env.openfaas-fn") {
+			t.Errorf("want no %s finding for an unresolved timeout, got: %v", rule, findings)
+		}
+	}
+	if hasFinding(findings, "OF-CHK-002", "upstream_timeout") {
+		t.Errorf("want no OF-CHK-002 finding for an unresolved upstream_timeout, got: %v", findings)
+	}
+}
+
+func Test_Analyze_UnparsableTimeouts(t *testing.T) {
+	snapshot := newSnapshot()
+	snapshot.Components.Gateway.Image = "ghcr.io/openfaas/gateway:0.25.2"
+	snapshot.Components.Gateway.Timeout.Additional["upstream_timeout"] = "1 minute"
+	snapshot.Components.QueueWorker = &QueueWorker{
+		Replicas:    3,
+		MaxInflight: 50,
+		AckWait:     "30",
+		Timeout:     NewTimeout(),
+	}
+
+	fn := newFunction("env")
+	fn.Timeout.WriteTimeout = "abc"
+	snapshot.Functions["openfaas-fn"] = []Function{fn}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	wants := []struct {
+		ruleID  string
+		message string
+	}{
+		{"OF-CHK-002", "could not parse upstream_timeout '1 minute' on gateway"},
+		{"OF-CHK-002", "could not parse ack_wait '30' on queue-worker"},
+		{"OF-CHK-003", "could not parse write_timeout 'abc' on env.openfaas-fn"},
+	}
+	for _, want := range wants {
+		if !hasFinding(findings, want.ruleID, want.message) {
+			t.Errorf("want %s: %q, got: %v", want.ruleID, want.message, findings)
+		}
+	}
+
+	// Rules which compare against the gateway's upstream_timeout are skipped
+	if hasFinding(findings, "OF-FN-005", "") || hasFinding(findings, "OF-QW-003", "") {
+		t.Errorf("want no comparisons with an unparsable upstream_timeout, got: %v", findings)
+	}
+}
+
+func Test_Analyze_CollectionErrors(t *testing.T) {
+	snapshot := newSnapshot()
+	snapshot.Errors = []string{"could not list namespaces: forbidden"}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	if !hasFinding(findings, "OF-CHK-001", "could not list namespaces: forbidden") {
+		t.Errorf("want a finding for the collection error, got: %v", findings)
+	}
+}
+
+func Test_Analyze_WatchdogTimeouts(t *testing.T) {
+	snapshot := newSnapshot()
+
+	classic := newFunction("classic")
+	classic.Watchdog = WatchdogConfig{Watchdog: WatchdogClassic, FProcess: "cat"}
+	classic.Timeout.ReadTimeout = "30"
+	classic.Timeout.WriteTimeout = ""
+	delete(classic.Timeout.Additional, "exec_timeout")
+
+	of := newFunction("of")
+	of.Watchdog = WatchdogConfig{Watchdog: WatchdogOf, Mode: "http"}
+	of.Timeout.WriteTimeout = ""
+	of.Timeout.Additional["exec_timeout"] = "30"
+
+	snapshot.Functions["openfaas-fn"] = []Function{classic, of}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	if hasFinding(findings, "OF-CHK-003", "classic") {
+		t.Errorf("want a bare integer to be parsed as seconds, got: %v", findings)
+	}
+	if !hasFinding(findings, "OF-FN-002", "classic.openfaas-fn write_timeout is not set, the classic-watchdog default of 5s applies") {
+		t.Errorf("want the classic-watchdog default for write_timeout, got: %v", findings)
+	}
+	if !hasFinding(findings, "OF-FN-003", "classic.openfaas-fn exec_timeout is not set, the classic-watchdog does not limit executions") {
+		t.Errorf("want the classic-watchdog default for exec_timeout, got: %v", findings)
+	}
+	if hasFinding(findings, "OF-FN-009", "classic") {
+		t.Errorf("want no exec_timeout comparison when the classic-watchdog has no limit, got: %v", findings)
+	}
+	if !hasFinding(findings, "OF-FN-009", "of.openfaas-fn exec_timeout (30s) is greater than write_timeout (10s)") {
+		t.Errorf("want exec_timeout compared with the of-watchdog default write_timeout, got: %v", findings)
+	}
+}
+
+func Test_Analyze_Probes(t *testing.T) {
+	snapshot := newSnapshot()
+
+	missing := newFunction("missing")
+	missing.Probes = &Probes{}
+
+	mismatched := newFunction("mismatched")
+	mismatched.Probes.HealthPath = "/healthz"
+	mismatched.Probes.ReadyPath = "/_/health"
+
+	slow := newFunction("slow")
+	slow.Probes.Readiness.InitialDelaySeconds = 60
+
+	custom := newFunction("custom")
+	custom.Timeout.Additional["exec_timeout"] = "30s"
+	custom.Probes.Liveness.Path = "/healthz"
+	custom.Probes.HealthPath = "/healthz"
+
+	snapshot.Functions["openfaas-fn"] = []Function{missing, mismatched, slow, custom}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	for _, want := range []struct{ rule, message string }{
+		{"OF-PRB-001", "missing.openfaas-fn has no readiness probe"},
+		{"OF-PRB-002", "missing.openfaas-fn has no liveness probe"},
+		{"OF-PRB-003", "mismatched.openfaas-fn liveness probe requests /_/health, but com.openfaas.health.http.path is /healthz"},
+		{"OF-PRB-004", "slow.openfaas-fn scales to zero and its readiness probe can take 1m2s"},
+		{"OF-PRB-005", "custom.openfaas-fn liveness probe restarts it after failing for 6s"},
+	} {
+		if !hasFinding(findings, want.rule, want.message) {
+			t.Errorf("want %s %q, got: %v", want.rule, want.message, findings)
+		}
+	}
+
+	if hasFinding(findings, "OF-PRB-003", "readiness probe") {
+		t.Errorf("want no finding for a readiness path which matches its annotation, got: %v", findings)
+	}
+
+	snapshot.Components.Gateway.ProbeFunctions = true
+	if hasFinding(Analyze(snapshot, DefaultConfig()), "OF-PRB-004", "slow") {
+		t.Errorf("want no OF-PRB-004 finding when the gateway probes functions")
+	}
+}
+
+func Test_Analyze_Resources(t *testing.T) {
+	snapshot := newSnapshot()
+	snapshot.Components.Resources = map[string]*ContainerResources{
+		"gateway": {
+			Requests: &FunctionResources{Memory: "120Mi", CPU: "50m"},
+			Limits:   &FunctionResources{Memory: "0", CPU: "0"},
+		},
+		"operator": {
+			Requests: &FunctionResources{Memory: "120Mi", CPU: "50m"},
+			Limits:   &FunctionResources{Memory: "256Mi", CPU: "0"},
+		},
+	}
+
+	inverted := newFunction("inverted")
+	inverted.Requests = &FunctionResources{Memory: "256Mi", CPU: "100m"}
+	inverted.Limits = &FunctionResources{Memory: "128Mi", CPU: "0"}
+
+	unlimited := newFunction("unlimited")
+	unlimited.Limits = &FunctionResources{Memory: "0", CPU: "500m"}
+
+	tiny := newFunction("tiny")
+	tiny.Requests = &FunctionResources{Memory: "4Mi", CPU: "1m"}
+
+	snapshot.Functions["openfaas-fn"] = []Function{inverted, unlimited, tiny}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	for _, want := range []struct{ rule, message string }{
+		{"OF-RES-001", "inverted.openfaas-fn memory requests (256Mi) are greater than its limit (128Mi)"},
+		{"OF-RES-002", "unlimited.openfaas-fn memory limit is <none>"},
+		{"OF-RES-003", "unlimited.openfaas-fn cpu limit is 500m but its cpu requests are <none>"},
+		{"OF-RES-004", "tiny.openfaas-fn memory requests (4Mi) are lower than 10Mi"},
+		{"OF-RES-004", "tiny.openfaas-fn cpu requests (1m) are lower than 5m"},
+		{"OF-RES-005", "gateway has no memory limit (requests: 120Mi memory, 50m cpu, limits: <none> memory)"},
+	} {
+		if !hasFinding(findings, want.rule, want.message) {
+			t.Errorf("want %s %q, got: %v", want.rule, want.message, findings)
+		}
+	}
+
+	if hasFinding(findings, "OF-RES-005", "operator") {
+		t.Errorf("want no finding for the operator, which has requests and a memory limit, got: %v", findings)
+	}
+	if hasFinding(findings, "OF-RES-001", "cpu") {
+		t.Errorf("want no cpu finding when there is no cpu limit, got: %v", findings)
+	}
+}
+
+func Test_Analyze_FunctionPodHealth(t *testing.T) {
+	cases := []struct {
+		name      string
+		container ContainerHealth
+		rule      string
+		want      string
+	}{
+		{
+			name:      "failing to pull its image",
+			container: ContainerHealth{Name: "env", Waiting: "ImagePullBackOff"},
+			rule:      "OF-POD-007",
+			want:      "env.openfaas-fn pod env-abc container env is in ImagePullBackOff",
+		},
+		{
+			name:      "creating is not failing",
+			container: ContainerHealth{Name: "env", Waiting: "ContainerCreating"},
+			rule:      "OF-POD-007",
+		},
+		{
+			name:      "OOMKilled",
+			container: ContainerHealth{Name: "env", Restarts: 1, LastTermination: "OOMKilled"},
+			rule:      "OF-POD-008",
+			want:      "env.openfaas-fn pod env-abc container env was OOMKilled, its memory limit may be too low",
+		},
+		{
+			name:      "exited with an error",
+			container: ContainerHealth{Name: "env", Restarts: 1, LastTermination: "Error"},
+			rule:      "OF-POD-008",
+		},
+		{
+			name:      "restarted more than the threshold",
+			container: ContainerHealth{Name: "env", Restarts: 6},
+			rule:      "OF-POD-009",
+			want:      "env.openfaas-fn pod env-abc container env has restarted 6 times, 5 or fewer is recommended",
+		},
+		{
+			name:      "restarted as often as the threshold",
+			container: ContainerHealth{Name: "env", Restarts: 5},
+			rule:      "OF-POD-009",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			snapshot := newSnapshot()
+			fn := newFunction("env")
+			fn.Health = &WorkloadHealth{
+				Replicas: 1,
+				Pods: []PodHealth{
+					{Name: "env-abc", Phase: "Running", Containers: []ContainerHealth{tc.container}},
+				},
+			}
+			snapshot.Functions["openfaas-fn"] = []Function{fn}
+
+			findings := Analyze(snapshot, DefaultConfig())
+
+			if len(tc.want) == 0 {
+				if hasFinding(findings, tc.rule, "") {
+					t.Errorf("want no %s finding, got: %v", tc.rule, findings)
+				}
+				return
+			}
+			if !hasFinding(findings, tc.rule, tc.want) {
+				t.Errorf("want %s %q, got: %v", tc.rule, tc.want, findings)
+			}
+		})
+	}
+}
+
+func Test_Analyze_CorePodHealth(t *testing.T) {
+	available := 1
+	snapshot := newSnapshot()
+	snapshot.CoreHealth = map[string]*WorkloadHealth{
+		"gateway": {
+			Replicas:  3,
+			Available: &available,
+			Pods: []PodHealth{
+				{Name: "gateway-abc", Phase: "Running", Containers: []ContainerHealth{
+					{Name: "gateway", Waiting: "CrashLoopBackOff", Restarts: 6, LastTermination: "OOMKilled"},
+				}},
+			},
+		},
+		"queue-worker": {
+			Replicas:  1,
+			Available: &available,
+			Pods: []PodHealth{
+				{Name: "queue-worker-abc", Phase: "Running", Containers: []ContainerHealth{
+					{Name: "queue-worker", Restarts: 5, LastTermination: "Error"},
+				}},
+			},
+		},
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	for _, want := range []struct{ rule, message string }{
+		{"OF-POD-001", "gateway has 1 of 3 replicas available"},
+		{"OF-POD-002", "gateway pod gateway-abc container gateway is in CrashLoopBackOff, it last exited with OOMKilled"},
+		{"OF-POD-003", "gateway pod gateway-abc container gateway was OOMKilled"},
+		{"OF-POD-004", "container gateway has restarted 6 times, 5 or fewer is recommended"},
+	} {
+		if !hasFinding(findings, want.rule, want.message) {
+			t.Errorf("want %s %q, got: %v", want.rule, want.message, findings)
+		}
+	}
+
+	for _, rule := range []string{"OF-POD-001", "OF-POD-002", "OF-POD-003", "OF-POD-004"} {
+		if hasFinding(findings, rule, "queue-worker") {
+			t.Errorf("want no %s finding for the healthy queue-worker, got: %v", rule, findings)
+		}
+	}
+}
+
+func Test_Analyze_FunctionUnavailable(t *testing.T) {
+	available := 0
+	snapshot := newSnapshot()
+	fn := newFunction("env")
+	fn.Health = &WorkloadHealth{Replicas: 2, Available: &available}
+	snapshot.Functions["openfaas-fn"] = []Function{fn}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	if want := "env.openfaas-fn has 0 of 2 replicas available"; !hasFinding(findings, "OF-POD-006", want) {
+		t.Errorf("want OF-POD-006 %q, got: %v", want, findings)
+	}
+
+	// Without collected health there is nothing to compare
+	fn.Health = nil
+	snapshot.Functions["openfaas-fn"] = []Function{fn}
+	if findings := Analyze(snapshot, DefaultConfig()); hasFinding(findings, "OF-POD-006", "") {
+		t.Errorf("want no OF-POD-006 finding without health, got: %v", findings)
+	}
+}
+
+func Test_Analyze_CorePodUnschedulable(t *testing.T) {
+	snapshot := newSnapshot()
+	snapshot.CoreHealth = map[string]*WorkloadHealth{
+		"gateway": {
+			Replicas: 3,
+			Pods: []PodHealth{
+				{Name: "gateway-abc", Phase: "Running"},
+				{Name: "gateway-def", Phase: "Pending", Unschedulable: "Unschedulable: 0/3 nodes are available: 3 Insufficient cpu."},
+			},
+		},
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	if !hasFinding(findings, "OF-POD-005", "gateway pod gateway-def is Pending, Unschedulable: 0/3 nodes are available: 3 Insufficient cpu.") {
+		t.Errorf("want OF-POD-005 for the Pending Pod, got: %v", findings)
+	}
+	if hasFinding(findings, "OF-POD-005", "gateway-abc") {
+		t.Errorf("want no OF-POD-005 for the running Pod, got: %v", findings)
+	}
+}
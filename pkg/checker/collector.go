@@ -0,0 +1,668 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Collector reads the configuration of OpenFaaS from the Kubernetes API
+type Collector struct {
+	client        kubernetes.Interface
+	dynamic       dynamic.Interface
+	coreNamespace string
+}
+
+// NewCollector returns a Collector for the OpenFaaS installation in
+// coreNamespace.
+func NewCollector(client kubernetes.Interface, coreNamespace string) *Collector {
+	return &Collector{
+		client:        client,
+		coreNamespace: coreNamespace,
+	}
+}
+
+// WithDynamicClient enables reading Function custom resources when the
+// operator is in use.
+func (c *Collector) WithDynamicClient(client dynamic.Interface) *Collector {
+	c.dynamic = client
+	return c
+}
+
+// Collect queries the Kubernetes API and returns a snapshot of the
+// core components and functions. Requests which fail, for instance due
+// to RBAC, are recorded in the snapshot's Errors and the rest of the
+// snapshot is still collected. An error is only returned when ctx is
+// cancelled.
+func (c *Collector) Collect(ctx context.Context) (*ClusterSnapshot, error) {
+	snapshot := &ClusterSnapshot{
+		CoreNamespace: c.coreNamespace,
+		Functions:     make(map[string][]Function),
+		IgnoredRules:  make(map[string][]string),
+	}
+
+	var deps []v1.Deployment
+	list, err := c.client.AppsV1().Deployments(c.coreNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		snapshot.addError("could not list core deployments: %s", err)
+	} else {
+		for _, dep := range list.Items {
+			if isCoreDeployment(dep) {
+				deps = append(deps, dep)
+			}
+		}
+	}
+
+	builderDeps, err := c.client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{
+		LabelSelector: "component=pro-builder,app.kubernetes.io/part-of=openfaas",
+	})
+	if err != nil {
+		snapshot.addError("could not list pro-builder deployments: %s", err)
+		builderDeps = &v1.DeploymentList{}
+	}
+
+	namespacesListed := true
+	namespaces, err := c.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		snapshot.addError("could not list namespaces: %s", err)
+		namespaces = &corev1.NamespaceList{}
+		namespacesListed = false
+	}
+
+	openfaasCoreNamespaceDetected := false
+
+	for _, n := range namespaces.Items {
+		if n.Name == c.coreNamespace {
+			openfaasCoreNamespaceDetected = true
+		}
+		if n.Name == "istio-system" {
+			snapshot.Istio = true
+		}
+
+		if ignored := parseIgnoreAnnotation(n.Annotations); len(ignored) > 0 {
+			snapshot.IgnoredRules[n.Name] = ignored
+		}
+	}
+
+	// When namespaces could not be listed, the error has already been
+	// recorded and the core namespace may still exist.
+	if !openfaasCoreNamespaceDetected && namespacesListed {
+		snapshot.addError("OpenFaaS Core namespace \"%s\" not found", c.coreNamespace)
+	}
+
+	lookup := c.configMapLookup(ctx, snapshot)
+
+	components, parseErrors := readComponents(deps, lookup)
+	snapshot.Components = *components
+	snapshot.ParseErrors = parseErrors
+
+	functionNamespaces, missing, unselected := selectFunctionNamespaces(components.Controller, namespaces.Items, namespacesListed)
+	snapshot.FunctionNamespaces = functionNamespaces
+	snapshot.MissingNamespaces = missing
+	snapshot.UnselectedNamespaces = unselected
+
+	if len(components.Controller.Mode) > 0 {
+		c.collectBindings(ctx, snapshot, functionNamespaces)
+	}
+
+	if len(deps) > 0 {
+		snapshot.CoreHealth = c.collectHealth(ctx, snapshot, c.coreNamespace, deps)
+		c.collectHA(ctx, snapshot, deps)
+	}
+
+	if len(builderDeps.Items) > 0 {
+		snapshot.FunctionBuilder = true
+	}
+
+	for _, namespace := range functionNamespaces {
+		list, err := c.client.AppsV1().
+			Deployments(namespace).
+			List(ctx, metav1.ListOptions{})
+		if err != nil {
+			snapshot.addError("could not list deployments in namespace %s: %s", namespace, err)
+			continue
+		}
+
+		functionDeps, otherDeps := splitFunctions(list.Items)
+
+		functions, errs := readFunctions(functionDeps, lookup)
+		health := c.collectHealth(ctx, snapshot, namespace, functionDeps)
+		for i := range functions {
+			functions[i].Health = health[functions[i].Name]
+		}
+		snapshot.Functions[namespace] = functions
+		snapshot.Errors = append(snapshot.Errors, errs...)
+
+		if unread := unreadFunctions(functionDeps, functions); len(unread) > 0 {
+			if snapshot.UnreadFunctions == nil {
+				snapshot.UnreadFunctions = make(map[string][]string)
+			}
+			snapshot.UnreadFunctions[namespace] = unread
+		}
+
+		if len(otherDeps) > 0 {
+			if snapshot.OtherWorkloads == nil {
+				snapshot.OtherWorkloads = make(map[string][]Workload)
+			}
+			snapshot.OtherWorkloads[namespace] = readWorkloads(otherDeps)
+		}
+	}
+
+	if snapshot.Components.Autoscaler != nil {
+		c.collectScalers(ctx, snapshot)
+	}
+
+	if snapshot.Components.Controller.Mode == "operator" && c.dynamic != nil {
+		snapshot.FunctionCRs = make(map[string][]FunctionCR)
+
+		for _, namespace := range functionNamespaces {
+			list, err := c.dynamic.Resource(FunctionsResource).
+				Namespace(namespace).
+				List(ctx, metav1.ListOptions{})
+			if err != nil {
+				snapshot.addError("could not list function CRs in namespace %s: %s", namespace, err)
+				continue
+			}
+
+			functions, errs := readFunctionCRs(list.Items)
+			snapshot.FunctionCRs[namespace] = functions
+			snapshot.Errors = append(snapshot.Errors, errs...)
+		}
+	}
+
+	k8sVer, err := c.client.Discovery().ServerVersion()
+	if err != nil {
+		snapshot.addError("could not get the Kubernetes version: %s", err)
+	} else {
+		snapshot.KubernetesVersion = k8sVer.String()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+func (s *ClusterSnapshot) addError(format string, a ...interface{}) {
+	s.Errors = append(s.Errors, fmt.Sprintf(format, a...))
+}
+
+// configMapLookup returns a lookup which reads each ConfigMap from the
+// API at most once, a ConfigMap which can not be read is recorded as an
+// error in the snapshot unless it is optional and does not exist.
+func (c *Collector) configMapLookup(ctx context.Context, snapshot *ClusterSnapshot) configMapLookup {
+	cache := map[string]map[string]string{}
+	failed := map[string]error{}
+	reported := map[string]bool{}
+
+	return func(namespace, name string, optional bool) (map[string]string, error) {
+		key := namespace + "/" + name
+		if data, ok := cache[key]; ok {
+			return data, nil
+		}
+
+		err, ok := failed[key]
+		if !ok {
+			cm, getErr := c.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+			if getErr == nil {
+				cache[key] = cm.Data
+				return cm.Data, nil
+			}
+			err = getErr
+			failed[key] = err
+		}
+
+		if !reported[key] && !(optional && apierrors.IsNotFound(err)) {
+			reported[key] = true
+			snapshot.addError("could not read configmap %s: %s", key, err)
+		}
+		return nil, err
+	}
+}
+
+// collectHealth lists the Pods and Events in a namespace and returns the
+// health of each Deployment by name. When the Pods can not be listed, the
+// health is still read from the status of each Deployment.
+func (c *Collector) collectHealth(ctx context.Context, snapshot *ClusterSnapshot, namespace string, deps []v1.Deployment) map[string]*WorkloadHealth {
+	pods, err := c.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		snapshot.addError("could not list pods in namespace %s: %s", namespace, err)
+		pods = &corev1.PodList{}
+	}
+
+	events, err := c.client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		snapshot.addError("could not list events in namespace %s: %s", namespace, err)
+		events = &corev1.EventList{}
+	}
+	summaries := summariseEvents(events.Items)
+
+	health := make(map[string]*WorkloadHealth, len(deps))
+	for _, dep := range deps {
+		health[dep.Name] = readHealth(dep, pods.Items)
+		health[dep.Name].Events = summaries[dep.Name]
+	}
+	return health
+}
+
+// readComponents reads the core components from the Deployments in the
+// OpenFaaS namespace, settings which can not be parsed are returned as
+// messages and keep their zero value.
+func readComponents(deps []v1.Deployment, lookup configMapLookup) (*Components, []string) {
+	components := &Components{
+		Gateway: Gateway{
+			Timeout: NewTimeout(),
+		},
+		Controller: Controller{
+			Timeout: NewTimeout(),
+		},
+	}
+
+	var parseErrors []string
+	parseBool := func(name, value, component string) bool {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			parseErrors = append(parseErrors, fmt.Sprintf("could not parse %s '%s' on %s", name, value, component))
+		}
+		return v
+	}
+	resolve := func(dep v1.Deployment, container corev1.Container) []envVar {
+		vars, problems := resolveEnv(dep.Namespace, container, lookup)
+		parseErrors = append(parseErrors, problems...)
+		return vars
+	}
+
+	for _, dep := range deps {
+
+		if dep.Name == "queue-worker" {
+			for _, container := range dep.Spec.Template.Spec.Containers {
+				if container.Name == "queue-worker" {
+					queueWorker := &QueueWorker{
+						Timeout:  NewTimeout(),
+						Replicas: replicas(dep),
+					}
+					for _, env := range resolve(dep, container) {
+						if env.Name == "ack_wait" {
+							queueWorker.AckWait = env.Value
+						}
+
+						if env.Name == "max_inflight" {
+							maxInflight, err := strconv.Atoi(env.Value)
+							if err != nil {
+								parseErrors = append(parseErrors, fmt.Sprintf("could not parse max_inflight '%s' on queue-worker", env.Value))
+							}
+							queueWorker.MaxInflight = maxInflight
+						}
+
+						if env.Name == "upstream_timeout" {
+							queueWorker.Timeout.set(env)
+						}
+					}
+					queueWorker.Image = container.Image
+					queueWorker.JetStream = strings.Contains(queueWorker.Image, "jetstream-queue-worker")
+					components.QueueWorker = queueWorker
+				}
+			}
+		}
+
+		if componentName(dep) == "gateway" {
+			for _, container := range dep.Spec.Template.Spec.Containers {
+				if container.Name == "gateway" {
+					gateway := &components.Gateway
+					gateway.Deployment = dep.Name
+					gateway.Replicas = replicas(dep)
+					for _, env := range resolve(dep, container) {
+						if env.Name == "read_timeout" {
+							gateway.Timeout.set(env)
+						}
+						if env.Name == "write_timeout" {
+							gateway.Timeout.set(env)
+						}
+						if env.Name == "upstream_timeout" {
+							gateway.Timeout.set(env)
+						}
+						if env.Name == "probe_functions" {
+							gateway.ProbeFunctions = parseBool(env.Name, env.Value, container.Name)
+						}
+						if env.Name == "direct_functions" {
+							gateway.DirectFunctions = parseBool(env.Name, env.Value, container.Name)
+						}
+					}
+					gateway.Image = container.Image
+					gateway.Pro = isProComponent(container)
+				}
+				if container.Name == "faas-netes" || container.Name == "operator" {
+					controller := &components.Controller
+					controller.Mode = container.Name
+					for _, env := range resolve(dep, container) {
+						if env.Name == "read_timeout" {
+							controller.Timeout.set(env)
+						}
+						if env.Name == "write_timeout" {
+							controller.Timeout.set(env)
+						}
+						if env.Name == "set_nonroot_user" {
+							controller.SetNonRootUser = parseBool(env.Name, env.Value, container.Name)
+						}
+						if env.Name == "cluster_role" {
+							controller.ClusterRole = parseBool(env.Name, env.Value, container.Name)
+						}
+						if env.Name == "function_namespace" {
+							controller.FunctionNamespace = env.Value
+						}
+					}
+					controller.Image = container.Image
+					controller.ServiceAccount = dep.Spec.Template.Spec.ServiceAccountName
+					if len(controller.ServiceAccount) == 0 {
+						controller.ServiceAccount = "default"
+					}
+				}
+			}
+		}
+
+		if dep.Name == "autoscaler" {
+			for _, container := range dep.Spec.Template.Spec.Containers {
+				if container.Name == "autoscaler" {
+					components.Autoscaler = &Autoscaler{
+						Image:    container.Image,
+						Replicas: replicas(dep),
+					}
+				}
+			}
+		}
+
+		if dep.Name == "dashboard" {
+			for _, container := range dep.Spec.Template.Spec.Containers {
+				if container.Name == "dashboard" {
+					dashboard := &Dashboard{
+						Image: container.Image,
+					}
+
+					for _, volumeMount := range container.VolumeMounts {
+						if volumeMount.Name == "dashboard-jwt" {
+							dashboard.JWTSecret = true
+						}
+					}
+					components.Dashboard = dashboard
+				}
+			}
+		}
+	}
+
+	for _, dep := range deps {
+		if dep.Name == "nats" {
+			for _, container := range dep.Spec.Template.Spec.Containers {
+				if container.Name == "nats" && components.QueueWorker != nil {
+					if dep.Labels["app"] == "openfaas" {
+						components.QueueWorker.InternalNats = true
+					}
+				}
+			}
+		}
+	}
+
+	components.Resources = readCoreResources(deps)
+
+	return components, parseErrors
+}
+
+// splitFunctions separates the Deployments which faas-netes or the
+// operator created for a function from other workloads in a function
+// namespace, such as a database.
+func splitFunctions(deps []v1.Deployment) ([]v1.Deployment, []v1.Deployment) {
+	var functions, others []v1.Deployment
+	for _, dep := range deps {
+		if isFunction(dep) {
+			functions = append(functions, dep)
+		} else {
+			others = append(others, dep)
+		}
+	}
+	return functions, others
+}
+
+// isFunction returns true when a Deployment has the faas_function label,
+// or is owned by a Function custom resource
+func isFunction(dep v1.Deployment) bool {
+	if _, ok := dep.Labels["faas_function"]; ok {
+		return true
+	}
+	if _, ok := dep.Spec.Template.Labels["faas_function"]; ok {
+		return true
+	}
+
+	for _, owner := range dep.OwnerReferences {
+		if owner.Kind == "Function" && strings.HasPrefix(owner.APIVersion, FunctionsResource.Group+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// functionName is the name of the function a Deployment was created for
+func functionName(dep v1.Deployment) string {
+	if name := dep.Labels["faas_function"]; len(name) > 0 {
+		return name
+	}
+	if name := dep.Spec.Template.Labels["faas_function"]; len(name) > 0 {
+		return name
+	}
+	return dep.Name
+}
+
+// unreadFunctions returns the names of the function Deployments which
+// readFunctions skipped
+func unreadFunctions(deps []v1.Deployment, functions []Function) []string {
+	read := map[string]bool{}
+	for _, fn := range functions {
+		read[fn.Name] = true
+	}
+
+	var unread []string
+	for _, dep := range deps {
+		if !read[dep.Name] {
+			unread = append(unread, dep.Name)
+		}
+	}
+	return unread
+}
+
+// readFunctions reads the functions from the Deployments in a function
+// namespace, Deployments which can not be read are skipped and returned
+// as messages.
+func readFunctions(deps []v1.Deployment, lookup configMapLookup) ([]Function, []string) {
+
+	var functions []Function
+	var problems []string
+
+	for _, dep := range deps {
+		// The function's container is named after it, any others are
+		// sidecars such as a service mesh's proxy
+		name := functionName(dep)
+		var functionContainer corev1.Container
+		found := false
+		for _, container := range dep.Spec.Template.Spec.Containers {
+			if container.Name == name {
+				functionContainer = container
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("deployment %s.%s has no container named %s", dep.Name, dep.Namespace, name))
+			continue
+		}
+
+		function := Function{
+			Name:     dep.Name,
+			Timeout:  NewTimeout(),
+			Replicas: replicas(dep),
+
+			IgnoredRules: parseIgnoreAnnotation(dep.Annotations),
+		}
+
+		vars, envProblems := resolveEnv(dep.Namespace, functionContainer, lookup)
+		problems = append(problems, envProblems...)
+		function.readEnv(vars)
+		function.readLabels(dep.Spec.Template.Labels)
+		function.Probes = readProbes(functionContainer, dep.Spec.Template.Annotations, dep.Annotations)
+
+		function.Requests, function.Limits = readResources(functionContainer)
+
+		if functionContainer.SecurityContext != nil {
+			if functionContainer.SecurityContext.ReadOnlyRootFilesystem != nil {
+				function.ReadOnlyRootFilesystem = *functionContainer.SecurityContext.ReadOnlyRootFilesystem
+			}
+		}
+		function.PodSecurity = evaluatePodSecurity(dep.Spec.Template.Spec)
+
+		functions = append(functions, function)
+	}
+
+	return functions, problems
+}
+
+// readEnv sets max_inflight, the timeouts and the watchdog of a function
+// from its environment
+func (f *Function) readEnv(vars []envVar) {
+	environment := map[string]string{}
+	for _, env := range vars {
+		environment[env.Name] = env.Value
+
+		if env.Name == "max_inflight" {
+			maxInflight, err := strconv.Atoi(env.Value)
+			if err == nil {
+				f.MaxInflight = &maxInflight
+			} else {
+				f.ParseErrors = append(f.ParseErrors, fmt.Sprintf("could not parse max_inflight '%s'", env.Value))
+			}
+		}
+
+		if env.Name == "read_timeout" {
+			f.Timeout.set(env)
+		}
+		if env.Name == "write_timeout" {
+			f.Timeout.set(env)
+		}
+		if env.Name == "exec_timeout" {
+			f.Timeout.set(env)
+		}
+	}
+	f.Watchdog = detectWatchdog(environment)
+}
+
+// readLabels sets the scaling configuration of a function from its labels
+func (f *Function) readLabels(labels map[string]string) {
+	scaleMax, ok := labels["com.openfaas.scale.max"]
+	if ok {
+		v, err := strconv.Atoi(scaleMax)
+		if err == nil {
+			if f.Scaling == nil {
+				f.Scaling = &Scaling{}
+			}
+			f.Scaling.Max = &v
+		} else {
+			f.ParseErrors = append(f.ParseErrors, fmt.Sprintf("could not parse com.openfaas.scale.max '%s'", scaleMax))
+		}
+	}
+	scaleMin, ok := labels["com.openfaas.scale.min"]
+	if ok {
+		v, err := strconv.Atoi(scaleMin)
+		if err == nil {
+			if f.Scaling == nil {
+				f.Scaling = &Scaling{}
+			}
+			f.Scaling.Min = &v
+		} else {
+			f.ParseErrors = append(f.ParseErrors, fmt.Sprintf("could not parse com.openfaas.scale.min '%s'", scaleMin))
+		}
+	}
+	scaleType, ok := labels["com.openfaas.scale.type"]
+	if ok {
+		if f.Scaling == nil {
+			f.Scaling = &Scaling{}
+		}
+		f.Scaling.Type = scaleType
+	}
+	scaleTarget, ok := labels["com.openfaas.scale.target"]
+	if ok {
+		if f.Scaling == nil {
+			f.Scaling = &Scaling{}
+		}
+		f.Scaling.Target = scaleTarget
+	}
+	scaleProportion, ok := labels["com.openfaas.scale.target-proportion"]
+	if ok {
+		if f.Scaling == nil {
+			f.Scaling = &Scaling{}
+		}
+		f.Scaling.Proportion = scaleProportion
+	}
+	scaleZero, ok := labels["com.openfaas.scale.zero"]
+	if ok {
+		if f.Scaling == nil {
+			f.Scaling = &Scaling{}
+		}
+		f.Scaling.Zero = scaleZero
+	}
+	scaleZeroDuration, ok := labels["com.openfaas.scale.zero-duration"]
+	if ok {
+		if f.Scaling == nil {
+			f.Scaling = &Scaling{}
+		}
+		f.Scaling.ZeroDuration = scaleZeroDuration
+	}
+}
+
+// replicas returns the replicas of a Deployment, which Kubernetes
+// defaults to 1 when it is not set.
+func replicas(dep v1.Deployment) int {
+	if dep.Spec.Replicas == nil {
+		return 1
+	}
+	return int(*dep.Spec.Replicas)
+}
+
+func isProComponent(container corev1.Container) bool {
+	return isProImage(container.Image) || hasLicenseMount(container)
+}
+
+func isProImage(imageName string) bool {
+	return strings.Contains(imageName, "openfaasltd")
+}
+
+func hasLicenseMount(container corev1.Container) bool {
+	for _, mount := range container.VolumeMounts {
+		if mount.Name == "license" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readWorkloads summarises the Deployments in a function namespace which
+// are not functions
+func readWorkloads(deps []v1.Deployment) []Workload {
+	workloads := make([]Workload, 0, len(deps))
+	for _, dep := range deps {
+		workload := Workload{
+			Name:     dep.Name,
+			Replicas: replicas(dep),
+		}
+		for _, container := range dep.Spec.Template.Spec.Containers {
+			workload.Images = append(workload.Images, container.Image)
+		}
+		workloads = append(workloads, workload)
+	}
+	return workloads
+}
@@ -0,0 +1,879 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_isProImage(t *testing.T) {
+	images := []struct {
+		name string
+		want bool
+	}{
+		{"ghcr.io/openfaas/gateway:0.23.2", false},
+		{"ghcr.io/openfaasltd/gateway:0.2.0", true},
+	}
+
+	for _, image := range images {
+		got := isProImage(image.name)
+		if got != image.want {
+			t.Errorf("Checking: %s returned '%v' while '%v' is expected", image.name, got, image.want)
+		}
+	}
+
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func newDeployment(namespace, name string, replicas int32, labels map[string]string, containers ...corev1.Container) *v1.Deployment {
+	return &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: v1.DeploymentSpec{
+			Replicas: int32Ptr(replicas),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: containers,
+				},
+			},
+		},
+	}
+}
+
+// ownedByFunction sets a Function custom resource as the owner of a
+// Deployment, as the operator does
+func ownedByFunction(dep *v1.Deployment) *v1.Deployment {
+	dep.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "openfaas.com/v1",
+		Kind:       "Function",
+		Name:       dep.Name,
+	}}
+	return dep
+}
+
+func newNamespace(name string, annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: annotations,
+		},
+	}
+}
+
+func env(pairs ...string) []corev1.EnvVar {
+	var vars []corev1.EnvVar
+	for i := 0; i+1 < len(pairs); i += 2 {
+		vars = append(vars, corev1.EnvVar{Name: pairs[i], Value: pairs[i+1]})
+	}
+	return vars
+}
+
+func Test_Collect(t *testing.T) {
+	core := map[string]string{"app": "openfaas"}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		newNamespace("staging-fn", map[string]string{"openfaas": "1"}),
+		newNamespace("istio-system", nil),
+		newDeployment("openfaas", "gateway", 3, core,
+			corev1.Container{
+				Name:  "gateway",
+				Image: "ghcr.io/openfaasltd/gateway:0.3.0",
+				Env:   env("upstream_timeout", "60s", "probe_functions", "true"),
+			},
+			corev1.Container{
+				Name:  "operator",
+				Image: "ghcr.io/openfaasltd/faas-netes:0.5.0",
+				Env:   env("cluster_role", "true", "set_nonroot_user", "true"),
+			}),
+		newDeployment("openfaas", "queue-worker", 2, core,
+			corev1.Container{
+				Name:  "queue-worker",
+				Image: "ghcr.io/openfaasltd/jetstream-queue-worker:0.3.0",
+				Env:   env("ack_wait", "30s", "max_inflight", "50"),
+			}),
+		newDeployment("openfaas", "nats", 1, core,
+			corev1.Container{Name: "nats", Image: "nats:2.9"}),
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "openfaas", Namespace: "openfaas"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: core}},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "env-timeouts", Namespace: "staging-fn"},
+			Data:       map[string]string{"write_timeout": "20s"},
+		},
+		newDeployment("staging-fn", "env", 1, map[string]string{
+			"faas_function":                    "env",
+			"com.openfaas.scale.zero":          "true",
+			"com.openfaas.scale.zero-duration": "15m",
+		},
+			corev1.Container{
+				Name:  "env",
+				Image: "ghcr.io/openfaas/alpine:latest",
+				Env:   env("read_timeout", "10s", "exec_timeout", "30s"),
+				EnvFrom: []corev1.EnvFromSource{{
+					ConfigMapRef: &corev1.ConfigMapEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "env-timeouts"},
+					},
+				}},
+				SecurityContext: &corev1.SecurityContext{
+					ReadOnlyRootFilesystem: boolPtr(true),
+					Privileged:             boolPtr(true),
+				},
+			}),
+	)
+
+	snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := []string{"openfaas-fn", "staging-fn"}; !reflect.DeepEqual(snapshot.FunctionNamespaces, want) {
+		t.Errorf("want function namespaces %v, got %v", want, snapshot.FunctionNamespaces)
+	}
+
+	features := snapshot.Features(DefaultConfig().Thresholds)
+	if !features.HAGateway || !features.OperatorMode || !features.JetStream || !features.Istio || !features.ProGateway {
+		t.Errorf("features not detected as expected: %+v", features)
+	}
+
+	if snapshot.Components.Controller.ClusterRole != true {
+		t.Errorf("want cluster_role to be true")
+	}
+
+	if got := snapshot.AsyncConcurrency(); got != 100 {
+		t.Errorf("want async concurrency 100, got %d", got)
+	}
+
+	if !snapshot.Components.QueueWorker.InternalNats {
+		t.Errorf("want internal NATS to be detected")
+	}
+
+	for _, name := range []string{"gateway", "operator", "queue-worker", "nats"} {
+		if r := snapshot.Components.Resources[name]; r == nil || r.Requests.GetMemory() != "<none>" {
+			t.Errorf("want the resources of %s to be read, got %+v", name, r)
+		}
+	}
+
+	functions := snapshot.Functions["staging-fn"]
+	if len(functions) != 1 {
+		t.Fatalf("want 1 function in staging-fn, got %d", len(functions))
+	}
+	if functions[0].Timeout.ReadTimeout != "10s" || functions[0].Scaling.GetZeroDuration() != "15m" {
+		t.Errorf("function not read as expected: %+v", functions[0])
+	}
+
+	timeout := functions[0].Timeout
+	if timeout.WriteTimeout != "20s" || timeout.Sources["write_timeout"] != "configmap/env-timeouts" {
+		t.Errorf("want write_timeout 20s from configmap/env-timeouts, got %s from %s", timeout.WriteTimeout, timeout.Sources["write_timeout"])
+	}
+
+	if !functions[0].ReadOnlyRootFilesystem {
+		t.Errorf("want a read-only root filesystem to be detected")
+	}
+	if got := functions[0].PodSecurity.Level; got != PodSecurityPrivileged {
+		t.Errorf("want a privileged container to need the %s level, got %s", PodSecurityPrivileged, got)
+	}
+}
+
+func Test_Collect_MissingCoreNamespace(t *testing.T) {
+	client := fake.NewSimpleClientset(newNamespace("default", nil))
+
+	snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := []string{`OpenFaaS Core namespace "openfaas" not found`}; !reflect.DeepEqual(snapshot.Errors, want) {
+		t.Errorf("want errors %v, got %v", want, snapshot.Errors)
+	}
+}
+
+func Test_Collect_RecordsErrorsAndContinues(t *testing.T) {
+	core := map[string]string{"app": "openfaas"}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		newDeployment("openfaas", "gateway", 1, core,
+			corev1.Container{
+				Name:  "gateway",
+				Image: "ghcr.io/openfaas/gateway:0.25.2",
+				Env:   env("upstream_timeout", "60s", "probe_functions", "yes"),
+			}),
+	)
+	client.PrependReactor("list", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() != "openfaas-fn" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "", fmt.Errorf("RBAC"))
+	})
+
+	snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if snapshot.Components.Gateway.Image != "ghcr.io/openfaas/gateway:0.25.2" {
+		t.Errorf("want the gateway to be collected, got: %+v", snapshot.Components.Gateway)
+	}
+
+	if len(snapshot.Errors) != 1 || !strings.Contains(snapshot.Errors[0], "could not list deployments in namespace openfaas-fn") {
+		t.Errorf("want an error for openfaas-fn, got: %v", snapshot.Errors)
+	}
+
+	if want := []string{"could not parse probe_functions 'yes' on gateway"}; !reflect.DeepEqual(snapshot.ParseErrors, want) {
+		t.Errorf("want parse errors %v, got %v", want, snapshot.ParseErrors)
+	}
+}
+
+func Test_Collect_OptionalConfigMaps(t *testing.T) {
+	core := map[string]string{"app": "openfaas"}
+	optional := true
+
+	fromConfigMap := func(name string, optional *bool) corev1.EnvFromSource {
+		return corev1.EnvFromSource{ConfigMapRef: &corev1.ConfigMapEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			Optional:             optional,
+		}}
+	}
+
+	fn := newDeployment("openfaas-fn", "env", 1, map[string]string{"faas_function": "env"},
+		corev1.Container{
+			Name:    "env",
+			Image:   "ghcr.io/openfaas/alpine:latest",
+			EnvFrom: []corev1.EnvFromSource{fromConfigMap("env-overrides", &optional), fromConfigMap("env-timeouts", nil)},
+			Env: []corev1.EnvVar{{Name: "write_timeout", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "env-secrets"},
+					Key:                  "write_timeout",
+				},
+			}}},
+		})
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		newDeployment("openfaas", "gateway", 1, core,
+			corev1.Container{Name: "gateway", Image: "ghcr.io/openfaas/gateway:0.25.2", Env: env("upstream_timeout", "60s")}),
+		fn,
+	)
+
+	snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{`could not read configmap openfaas-fn/env-timeouts: configmaps "env-timeouts" not found`}
+	if !reflect.DeepEqual(snapshot.Errors, want) {
+		t.Errorf("want only the ConfigMap which is not optional to be reported %v, got %v", want, snapshot.Errors)
+	}
+
+	timeout := snapshot.Functions["openfaas-fn"][0].Timeout
+	if !timeout.IsUnresolved("write_timeout") || timeout.Sources["write_timeout"] != "secret/env-secrets:write_timeout" {
+		t.Errorf("want write_timeout to be unresolved from the Secret, got %+v", timeout)
+	}
+}
+
+func newFunctionCR(namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "openfaas.com/v1",
+		"kind":       "Function",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"spec": spec,
+	}}
+}
+
+func Test_Collect_FunctionCRs(t *testing.T) {
+	core := map[string]string{"app": "openfaas"}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		newDeployment("openfaas", "gateway", 1, core,
+			corev1.Container{Name: "gateway", Image: "ghcr.io/openfaasltd/gateway:0.3.0"},
+			corev1.Container{Name: "operator", Image: "ghcr.io/openfaasltd/faas-netes:0.5.0"}),
+		ownedByFunction(newDeployment("openfaas-fn", "env", 1, nil,
+			corev1.Container{Name: "env", Image: "ghcr.io/openfaas/alpine:latest"})),
+		newDeployment("openfaas-fn", "legacy", 1, map[string]string{"faas_function": "legacy"},
+			corev1.Container{Name: "legacy", Image: "ghcr.io/openfaas/alpine:latest"}),
+	)
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{FunctionsResource: "FunctionList"},
+		newFunctionCR("openfaas-fn", "env", map[string]interface{}{
+			"name":   "env",
+			"image":  "ghcr.io/openfaas/alpine:latest",
+			"labels": map[string]interface{}{"com.openfaas.scale.max": "ten"},
+		}),
+		newFunctionCR("openfaas-fn", "pending", map[string]interface{}{
+			"name":  "pending",
+			"image": "ghcr.io/openfaas/alpine:latest",
+		}),
+	)
+
+	snapshot, err := NewCollector(client, "openfaas").
+		WithDynamicClient(dynamicClient).
+		Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := len(snapshot.FunctionCRs["openfaas-fn"]); got != 2 {
+		t.Fatalf("want 2 function CRs, got %d", got)
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	wants := []struct {
+		ruleID  string
+		message string
+	}{
+		{"OF-CR-001", "function CR pending.openfaas-fn has no Deployment"},
+		{"OF-CR-002", "legacy.openfaas-fn has no Function CR"},
+		{"OF-CR-003", "function CR env.openfaas-fn label com.openfaas.scale.max 'ten' is not a whole number"},
+	}
+	for _, want := range wants {
+		if !hasFinding(findings, want.ruleID, want.message) {
+			t.Errorf("want %s: %q, got: %v", want.ruleID, want.message, findings)
+		}
+	}
+
+	if hasFinding(findings, "OF-CR-002", "env.openfaas-fn") {
+		t.Errorf("want no OF-CR-002 finding for env, which has a CR")
+	}
+}
+
+// A Deployment which can not be read as a function still exists for its CR
+func Test_Collect_FunctionCRs_UnreadDeployment(t *testing.T) {
+	core := map[string]string{"app": "openfaas"}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		newDeployment("openfaas", "gateway", 1, core,
+			corev1.Container{Name: "gateway", Image: "ghcr.io/openfaasltd/gateway:0.3.0"},
+			corev1.Container{Name: "operator", Image: "ghcr.io/openfaasltd/faas-netes:0.5.0"}),
+		ownedByFunction(newDeployment("openfaas-fn", "env", 1, nil,
+			corev1.Container{Name: "main", Image: "ghcr.io/openfaas/alpine:latest"})),
+	)
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{FunctionsResource: "FunctionList"},
+		newFunctionCR("openfaas-fn", "env", map[string]interface{}{
+			"name":  "env",
+			"image": "ghcr.io/openfaas/alpine:latest",
+		}),
+	)
+
+	snapshot, err := NewCollector(client, "openfaas").
+		WithDynamicClient(dynamicClient).
+		Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{"env"}
+	if got := snapshot.UnreadFunctions["openfaas-fn"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("want unread functions %v, got %v", want, got)
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+	if hasFinding(findings, "OF-CR-001", "env.openfaas-fn") {
+		t.Errorf("want no OF-CR-001 finding for env, which has a Deployment, got: %v", findings)
+	}
+	if !hasFinding(findings, "OF-CHK-001", "deployment env.openfaas-fn has no container named env") {
+		t.Errorf("want the unread Deployment to be reported, got: %v", findings)
+	}
+}
+
+func newPod(namespace, name string, labels map[string]string, phase corev1.PodPhase, statuses ...corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Status: corev1.PodStatus{
+			Phase:             phase,
+			ContainerStatuses: statuses,
+		},
+	}
+}
+
+func Test_Collect_PodHealth(t *testing.T) {
+	core := map[string]string{"app": "openfaas"}
+
+	gateway := newDeployment("openfaas", "gateway", 1, core,
+		corev1.Container{Name: "gateway", Image: "ghcr.io/openfaas/gateway:0.25.2"})
+	gateway.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "openfaas"}}
+	gateway.Status = v1.DeploymentStatus{ObservedGeneration: 1, AvailableReplicas: 0}
+
+	fn := newDeployment("openfaas-fn", "env", 2, map[string]string{"faas_function": "env"},
+		corev1.Container{Name: "env", Image: "ghcr.io/openfaas/alpine:latest"})
+	fn.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"faas_function": "env"}}
+
+	crashing := newPod("openfaas", "gateway-7d9f-abcde", core, corev1.PodRunning, corev1.ContainerStatus{
+		Name:         "gateway",
+		RestartCount: 12,
+		State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+		},
+		LastTerminationState: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"},
+		},
+	})
+	pending := newPod("openfaas-fn", "env-6c8b-fghij", map[string]string{"faas_function": "env"}, corev1.PodPending)
+	pending.Status.Conditions = []corev1.PodCondition{{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  "Unschedulable",
+		Message: "0/3 nodes are available: 3 Insufficient memory.",
+	}}
+	completed := newPod("openfaas-fn", "env-6c8b-klmno", map[string]string{"faas_function": "env"}, corev1.PodSucceeded)
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "env-6c8b-fghij.1", Namespace: "openfaas-fn"},
+		Type:           corev1.EventTypeWarning,
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "env-6c8b-fghij", Namespace: "openfaas-fn"},
+		Reason:         "FailedScheduling",
+		Message:        "0/3 nodes are available: 3 Insufficient memory.",
+		Count:          4,
+	}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		gateway, fn, crashing, pending, completed, event,
+	)
+
+	snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	gw := snapshot.CoreHealth["gateway"]
+	if gw == nil || gw.Available == nil || *gw.Available != 0 || len(gw.Pods) != 1 {
+		t.Fatalf("want the gateway's status and Pod, got: %+v", gw)
+	}
+	want := ContainerHealth{Name: "gateway", Restarts: 12, Waiting: "CrashLoopBackOff", LastTermination: "OOMKilled"}
+	if !reflect.DeepEqual(gw.Pods[0].Containers, []ContainerHealth{want}) {
+		t.Errorf("want container %+v, got %+v", want, gw.Pods[0].Containers)
+	}
+
+	health := snapshot.Functions["openfaas-fn"][0].Health
+	if health == nil || health.Available != nil {
+		t.Fatalf("want no availability for a Deployment without a status, got: %+v", health)
+	}
+	if len(health.Pods) != 1 || health.Pods[0].Unschedulable != "Unschedulable: 0/3 nodes are available: 3 Insufficient memory." {
+		t.Errorf("want only the Pending Pod with its scheduling message, got: %+v", health.Pods)
+	}
+
+	if len(health.Events) != 1 || health.Events[0].Reason != "FailedScheduling" || health.Events[0].Count != 4 {
+		t.Errorf("want the FailedScheduling event attached to the function, got: %+v", health.Events)
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+	for _, want := range []struct{ rule, message string }{
+		{"OF-POD-001", "gateway has 0 of 1 replicas available"},
+		{"OF-POD-002", "gateway pod gateway-7d9f-abcde container gateway is in CrashLoopBackOff, it last exited with OOMKilled"},
+		{"OF-POD-003", "gateway pod gateway-7d9f-abcde container gateway was OOMKilled"},
+		{"OF-POD-004", "container gateway has restarted 12 times, 5 or fewer is recommended"},
+		{"OF-POD-010", "env.openfaas-fn pod env-6c8b-fghij is Pending, Unschedulable: 0/3 nodes"},
+	} {
+		if !hasFinding(findings, want.rule, want.message) {
+			t.Errorf("want %s %q, got: %v", want.rule, want.message, findings)
+		}
+	}
+	if hasFinding(findings, "OF-POD-006", "env") {
+		t.Errorf("want no availability finding without a status, got: %v", findings)
+	}
+}
+
+func Test_Collect_ExternalScalers(t *testing.T) {
+	core := map[string]string{"app": "openfaas"}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "openfaas-fn"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "env"},
+		},
+	}
+	// Created by KEDA for the ScaledObject, which is reported instead
+	kedaHPA := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "keda-hpa-queue",
+			Namespace: "openfaas-fn",
+			Labels:    map[string]string{"scaledobject.keda.sh/name": "queue"},
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "queue"},
+		},
+	}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		newDeployment("openfaas", "gateway", 1, core,
+			corev1.Container{Name: "gateway", Image: "ghcr.io/openfaasltd/gateway:0.3.0"},
+			corev1.Container{Name: "faas-netes", Image: "ghcr.io/openfaasltd/faas-netes:0.5.0"}),
+		newDeployment("openfaas", "autoscaler", 1, core,
+			corev1.Container{Name: "autoscaler", Image: "ghcr.io/openfaasltd/autoscaler:0.2.0"}),
+		newDeployment("openfaas-fn", "env", 1, map[string]string{"faas_function": "env", "com.openfaas.scale.max": "10"},
+			corev1.Container{Name: "env", Image: "ghcr.io/openfaas/alpine:latest"}),
+		newDeployment("openfaas-fn", "queue", 1, map[string]string{"faas_function": "queue"},
+			corev1.Container{Name: "queue", Image: "ghcr.io/openfaas/alpine:latest"}),
+		newDeployment("openfaas-fn", "resized", 1, map[string]string{"faas_function": "resized"},
+			corev1.Container{Name: "resized", Image: "ghcr.io/openfaas/alpine:latest"}),
+		hpa, kedaHPA,
+	)
+	client.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "keda.sh/v1alpha1", APIResources: []metav1.APIResource{{Name: "scaledobjects"}}},
+		{GroupVersion: "autoscaling.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "verticalpodautoscalers"}}},
+	}
+
+	newObject := func(apiVersion, kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": "openfaas-fn"},
+			"spec":       spec,
+		}}
+	}
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			ScaledObjectsResource:          "ScaledObjectList",
+			VerticalPodAutoscalersResource: "VerticalPodAutoscalerList",
+		},
+		newObject("keda.sh/v1alpha1", "ScaledObject", "queue", map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"name": "queue"},
+		}),
+		newObject("autoscaling.k8s.io/v1", "VerticalPodAutoscaler", "resized", map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "resized"},
+		}),
+		newObject("autoscaling.k8s.io/v1", "VerticalPodAutoscaler", "recommend", map[string]interface{}{
+			"targetRef":    map[string]interface{}{"kind": "Deployment", "name": "env"},
+			"updatePolicy": map[string]interface{}{"updateMode": "Off"},
+		}),
+	)
+
+	snapshot, err := NewCollector(client, "openfaas").
+		WithDynamicClient(dynamicClient).
+		Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(snapshot.Errors) > 0 {
+		t.Fatalf("want no errors, got: %v", snapshot.Errors)
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	for _, want := range []struct{ rule, message string }{
+		{"OF-AS-003", "env.openfaas-fn is scaled by HorizontalPodAutoscaler/env and by the OpenFaaS autoscaler using its com.openfaas.scale labels"},
+		{"OF-AS-003", "queue.openfaas-fn is scaled by ScaledObject/queue and by the OpenFaaS autoscaler using its default scaling configuration"},
+		{"OF-AS-004", "resized.openfaas-fn is resized by VerticalPodAutoscaler/resized (updateMode: Auto)"},
+	} {
+		if !hasFinding(findings, want.rule, want.message) {
+			t.Errorf("want %s %q, got: %v", want.rule, want.message, findings)
+		}
+	}
+
+	if hasFinding(findings, "OF-AS-003", "keda-hpa-queue") {
+		t.Errorf("want the HPA created by KEDA to be skipped, got: %v", findings)
+	}
+	if hasFinding(findings, "OF-AS-004", "recommend") {
+		t.Errorf("want a VPA with updateMode Off to be skipped, got: %v", findings)
+	}
+}
+
+// Clusters before Kubernetes 1.23 do not serve autoscaling/v2
+func Test_Collect_ExternalScalers_AutoscalingV1(t *testing.T) {
+	core := map[string]string{"app": "openfaas"}
+
+	for _, v1Served := range []bool{true, false} {
+		client := fake.NewSimpleClientset(
+			newNamespace("openfaas", nil),
+			newNamespace("openfaas-fn", nil),
+			newDeployment("openfaas", "gateway", 1, core,
+				corev1.Container{Name: "gateway", Image: "ghcr.io/openfaasltd/gateway:0.3.0"},
+				corev1.Container{Name: "faas-netes", Image: "ghcr.io/openfaasltd/faas-netes:0.5.0"}),
+			newDeployment("openfaas", "autoscaler", 1, core,
+				corev1.Container{Name: "autoscaler", Image: "ghcr.io/openfaasltd/autoscaler:0.2.0"}),
+			newDeployment("openfaas-fn", "env", 1, map[string]string{"faas_function": "env"},
+				corev1.Container{Name: "env", Image: "ghcr.io/openfaas/alpine:latest"}),
+			&autoscalingv1.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "env", Namespace: "openfaas-fn"},
+				Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{Kind: "Deployment", Name: "env"},
+				},
+			},
+		)
+		client.PrependReactor("list", "horizontalpodautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetResource().Version == "v1" && v1Served {
+				return false, nil, nil
+			}
+			return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+		})
+
+		snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if len(snapshot.Errors) > 0 {
+			t.Errorf("want no errors when autoscaling/v2 is not served, got: %v", snapshot.Errors)
+		}
+
+		found := hasFinding(Analyze(snapshot, DefaultConfig()), "OF-AS-003", "env.openfaas-fn is scaled by HorizontalPodAutoscaler/env")
+		if found != v1Served {
+			t.Errorf("with autoscaling/v1 served %v, want the HPA to be found %v", v1Served, v1Served)
+		}
+	}
+}
+
+func newNode(name, zone string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"topology.kubernetes.io/zone": zone},
+		},
+	}
+}
+
+func Test_Collect_HA(t *testing.T) {
+	gatewayLabels := map[string]string{"app": "gateway"}
+	queueWorkerLabels := map[string]string{"app": "queue-worker"}
+
+	gateway := newDeployment("openfaas", "gateway", 3, gatewayLabels,
+		corev1.Container{Name: "gateway", Image: "ghcr.io/openfaas/gateway:0.25.2"})
+	gateway.Labels = map[string]string{"app": "openfaas"}
+	gateway.Spec.Selector = &metav1.LabelSelector{MatchLabels: gatewayLabels}
+
+	queueWorker := newDeployment("openfaas", "queue-worker", 2, queueWorkerLabels,
+		corev1.Container{Name: "queue-worker", Image: "ghcr.io/openfaas/queue-worker:0.13.1"})
+	queueWorker.Labels = map[string]string{"app": "openfaas"}
+	queueWorker.Spec.Selector = &metav1.LabelSelector{MatchLabels: queueWorkerLabels}
+	queueWorker.Spec.Template.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+		MaxSkew:     1,
+		TopologyKey: "kubernetes.io/hostname",
+	}}
+
+	running := func(name, node string, labels map[string]string) *corev1.Pod {
+		pod := newPod("openfaas", name, labels, corev1.PodRunning)
+		pod.Spec.NodeName = node
+		return pod
+	}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		newNode("node-1", "zone-a"),
+		newNode("node-2", "zone-a"),
+		newNode("node-3", "zone-b"),
+		gateway, queueWorker,
+		running("gateway-1", "node-1", gatewayLabels),
+		running("gateway-2", "node-1", gatewayLabels),
+		running("gateway-3", "node-1", gatewayLabels),
+		running("queue-worker-1", "node-1", queueWorkerLabels),
+		running("queue-worker-2", "node-2", queueWorkerLabels),
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "queue-worker", Namespace: "openfaas"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: queueWorkerLabels}},
+		},
+	)
+
+	snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := &HighAvailability{
+		PodDisruptionBudgets: []string{"queue-worker"},
+		TopologySpread:       true,
+		Running:              2,
+		Nodes:                2,
+		Zones:                1,
+		ClusterZones:         2,
+	}
+	if got := snapshot.HA["queue-worker"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("want queue-worker HA %+v, got %+v", want, got)
+	}
+
+	if snapshot.Features(DefaultConfig().Thresholds).HAGateway {
+		t.Errorf("want the gateway not to be HA with all of its replicas on one node")
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+	for _, want := range []struct{ rule, message string }{
+		{"OF-HA-001", "gateway has 3 replicas but no PodDisruptionBudget"},
+		{"OF-HA-002", "gateway has 3 replicas but no podAntiAffinity or topologySpreadConstraints"},
+		{"OF-HA-003", "gateway has 3 running replicas, but they are all on the same node"},
+		{"OF-HA-004", "queue-worker has 2 running replicas all in one zone, but the cluster's nodes are in 2 zones"},
+	} {
+		if !hasFinding(findings, want.rule, want.message) {
+			t.Errorf("want %s %q, got: %v", want.rule, want.message, findings)
+		}
+	}
+
+	for _, rule := range []string{"OF-HA-001", "OF-HA-002", "OF-HA-003"} {
+		if hasFinding(findings, rule, "queue-worker") {
+			t.Errorf("want no %s finding for the queue-worker, got: %v", rule, findings)
+		}
+	}
+}
+
+// An empty selector selects every Pod in the namespace, a nil one none
+func Test_Collect_HA_EmptyPDBSelector(t *testing.T) {
+	gatewayLabels := map[string]string{"app": "gateway"}
+
+	gateway := newDeployment("openfaas", "gateway", 3, gatewayLabels,
+		corev1.Container{Name: "gateway", Image: "ghcr.io/openfaas/gateway:0.25.2"})
+	gateway.Labels = map[string]string{"app": "openfaas"}
+	gateway.Spec.Selector = &metav1.LabelSelector{MatchLabels: gatewayLabels}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		gateway,
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "everything", Namespace: "openfaas"},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{}},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "nothing", Namespace: "openfaas"},
+		},
+	)
+
+	snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{"everything"}
+	if got := snapshot.HA["gateway"].PodDisruptionBudgets; !reflect.DeepEqual(got, want) {
+		t.Errorf("want gateway PodDisruptionBudgets %v, got %v", want, got)
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+	if hasFinding(findings, "OF-HA-001", "gateway") {
+		t.Errorf("want no OF-HA-001 finding for the gateway, got: %v", findings)
+	}
+}
+
+// The client returns an empty list along with an error, which must not be
+// read as there being no PodDisruptionBudgets
+func Test_Collect_HA_PDBListFails(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{"forbidden", apierrors.NewForbidden(policyv1.Resource("poddisruptionbudgets"), "", fmt.Errorf("RBAC"))},
+		{"not served", apierrors.NewNotFound(policyv1.Resource("poddisruptionbudgets"), "")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gatewayLabels := map[string]string{"app": "gateway"}
+			gateway := newDeployment("openfaas", "gateway", 3, gatewayLabels,
+				corev1.Container{Name: "gateway", Image: "ghcr.io/openfaas/gateway:0.25.2"})
+			gateway.Labels = map[string]string{"app": "openfaas"}
+
+			client := fake.NewSimpleClientset(
+				newNamespace("openfaas", nil),
+				newNamespace("openfaas-fn", nil),
+				gateway,
+			)
+			client.PrependReactor("list", "poddisruptionbudgets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &policyv1.PodDisruptionBudgetList{}, tc.err
+			})
+
+			snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := snapshot.HA["gateway"].PodDisruptionBudgets; got != nil {
+				t.Errorf("want the gateway's PodDisruptionBudgets to be unknown, got %v", got)
+			}
+
+			findings := Analyze(snapshot, DefaultConfig())
+			if hasFinding(findings, "OF-HA-001", "") {
+				t.Errorf("want no OF-HA-001 finding when the budgets were not listed, got: %v", findings)
+			}
+		})
+	}
+}
+
+func Test_Collect_OtherWorkloads(t *testing.T) {
+	env := newDeployment("openfaas-fn", "env", 1, map[string]string{"faas_function": "env"},
+		corev1.Container{Name: "istio-proxy", Image: "docker.io/istio/proxyv2:1.17.1"},
+		corev1.Container{
+			Name:  "env",
+			Image: "ghcr.io/openfaas/alpine:latest",
+			Env:   env("exec_timeout", "30s"),
+		})
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		env,
+		newDeployment("openfaas-fn", "broken", 1, map[string]string{"faas_function": "broken"},
+			corev1.Container{Name: "main", Image: "ghcr.io/openfaas/alpine:latest"}),
+		newDeployment("openfaas-fn", "redis", 1, map[string]string{"app": "redis"},
+			corev1.Container{Name: "redis", Image: "redis:7"},
+			corev1.Container{Name: "exporter", Image: "oliver006/redis_exporter:v1.50.0"}),
+	)
+
+	snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	functions := snapshot.Functions["openfaas-fn"]
+	if len(functions) != 1 || functions[0].Name != "env" {
+		t.Fatalf("want only the env function, got %+v", functions)
+	}
+	if got := functions[0].Timeout.Additional["exec_timeout"]; got != "30s" {
+		t.Errorf("want exec_timeout to be read from the env container rather than the sidecar, got %q", got)
+	}
+
+	want := []Workload{{Name: "redis", Replicas: 1, Images: []string{"redis:7", "oliver006/redis_exporter:v1.50.0"}}}
+	if got := snapshot.OtherWorkloads["openfaas-fn"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("want other workloads %+v, got %+v", want, got)
+	}
+
+	if want := "deployment broken.openfaas-fn has no container named broken"; !contains(snapshot.Errors, want) {
+		t.Errorf("want error %q, got %v", want, snapshot.Errors)
+	}
+}
@@ -0,0 +1,114 @@
+package checker
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Config changes how the rules are evaluated. It can be read from a YAML
+// file, for instance to allow a development cluster to run one gateway.
+//
+//	thresholds:
+//	  gatewayReplicas: 1
+//	  minScaleToZeroDuration: 2m
+type Config struct {
+	Thresholds Thresholds `json:"thresholds"`
+}
+
+// Thresholds are the limits used by the rules, any which are left out of
+// a config file keep their default value.
+type Thresholds struct {
+	// GatewayReplicas is the minimum for the gateway to be HA
+	GatewayReplicas int `json:"gatewayReplicas"`
+
+	// QueueWorkerReplicas is the minimum for the queue-worker to be HA
+	QueueWorkerReplicas int `json:"queueWorkerReplicas"`
+
+	// MinAsyncConcurrency is the lowest recommended replicas * max_inflight
+	// across all queue-workers
+	MinAsyncConcurrency int `json:"minAsyncConcurrency"`
+
+	// MaxQueueWorkerInflight is the highest recommended max_inflight
+	MaxQueueWorkerInflight int `json:"maxQueueWorkerInflight"`
+
+	// MinScaleToZeroDuration is the shortest recommended idle time before
+	// a function scales to zero
+	MinScaleToZeroDuration metav1.Duration `json:"minScaleToZeroDuration"`
+
+	// MinAckWait and MaxAckWait are the range recommended for ack_wait
+	// when using JetStream
+	MinAckWait metav1.Duration `json:"minAckWait"`
+	MaxAckWait metav1.Duration `json:"maxAckWait"`
+
+	// MaxContainerRestarts is the most restarts of a container before it
+	// is reported
+	MaxContainerRestarts int `json:"maxContainerRestarts"`
+
+	// MinMemoryRequest and MinCPURequest are the lowest requests of a
+	// function before they are reported as too low to be scheduled fairly
+	MinMemoryRequest resource.Quantity `json:"minMemoryRequest"`
+	MinCPURequest    resource.Quantity `json:"minCPURequest"`
+}
+
+// DefaultConfig returns the recommended thresholds for a production
+// cluster.
+func DefaultConfig() Config {
+	return Config{
+		Thresholds: Thresholds{
+			GatewayReplicas:        3,
+			QueueWorkerReplicas:    3,
+			MinAsyncConcurrency:    100,
+			MaxQueueWorkerInflight: 500,
+			MinScaleToZeroDuration: metav1.Duration{Duration: 5 * time.Minute},
+			MinAckWait:             metav1.Duration{Duration: 30 * time.Second},
+			MaxAckWait:             metav1.Duration{Duration: 1 * time.Minute},
+			MaxContainerRestarts:   5,
+			MinMemoryRequest:       resource.MustParse("10Mi"),
+			MinCPURequest:          resource.MustParse("5m"),
+		},
+	}
+}
+
+// ParseConfig reads a YAML config over the defaults, unknown fields are
+// an error so that typos are not ignored.
+func ParseConfig(data []byte) (Config, error) {
+	config := DefaultConfig()
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return Config{}, err
+	}
+
+	t := config.Thresholds
+	if t.GatewayReplicas < 0 || t.QueueWorkerReplicas < 0 || t.MinAsyncConcurrency < 0 || t.MaxQueueWorkerInflight < 0 || t.MaxContainerRestarts < 0 {
+		return Config{}, fmt.Errorf("thresholds must not be negative")
+	}
+
+	if t.MinMemoryRequest.Sign() < 0 || t.MinCPURequest.Sign() < 0 {
+		return Config{}, fmt.Errorf("thresholds must not be negative")
+	}
+
+	if t.MinAckWait.Duration > t.MaxAckWait.Duration {
+		return Config{}, fmt.Errorf("minAckWait (%s) must not be greater than maxAckWait (%s)", t.MinAckWait.Duration, t.MaxAckWait.Duration)
+	}
+
+	return config, nil
+}
+
+// ReadConfig reads a YAML config from a file
+func ReadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	config, err := ParseConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,52 @@
+package checker
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ParseConfig_KeepsDefaults(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+thresholds:
+  gatewayReplicas: 1
+  minScaleToZeroDuration: 2m
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := config.Thresholds
+	if got.GatewayReplicas != 1 {
+		t.Errorf("want gatewayReplicas 1, got %d", got.GatewayReplicas)
+	}
+	if got.MinScaleToZeroDuration.Duration != 2*time.Minute {
+		t.Errorf("want minScaleToZeroDuration 2m, got %s", got.MinScaleToZeroDuration.Duration)
+	}
+
+	defaults := DefaultConfig().Thresholds
+	if got.QueueWorkerReplicas != defaults.QueueWorkerReplicas || got.MaxAckWait != defaults.MaxAckWait {
+		t.Errorf("want thresholds which are not set to keep their defaults, got %+v", got)
+	}
+}
+
+func Test_ParseConfig_Invalid(t *testing.T) {
+	cases := []struct {
+		name string
+		yaml string
+	}{
+		{"unknown field", "thresholds:\n  gatewayReplica: 1\n"},
+		{"bad duration", "thresholds:\n  minAckWait: soon\n"},
+		{"min greater than max", "thresholds:\n  minAckWait: 2m\n  maxAckWait: 1m\n"},
+		{"negative", "thresholds:\n  gatewayReplicas: -1\n"},
+		{"negative quantity", "thresholds:\n  minMemoryRequest: -10Mi\n"},
+		{"bad quantity", "thresholds:\n  minCPURequest: lots\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := ParseConfig([]byte(c.yaml)); err == nil {
+				t.Errorf("want an error")
+			}
+		})
+	}
+}
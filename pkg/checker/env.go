@@ -0,0 +1,116 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const envSourceLiteral = "env"
+
+// envVar is an environment variable of a container and where its value
+// was read from, such as "env" or "configmap/name".
+type envVar struct {
+	Name   string
+	Value  string
+	Source string
+
+	// Unresolved is true when the value is set from a Secret or the Pod's
+	// fields, which are not read, so Value is empty
+	Unresolved bool
+}
+
+// configMapLookup returns the data of a ConfigMap, an optional ConfigMap
+// which does not exist is not reported
+type configMapLookup func(namespace, name string, optional bool) (map[string]string, error)
+
+// resolveEnv returns the environment of a container in the order that
+// Kubernetes applies it, so a later entry overrides an earlier one with
+// the same name. Values referenced from ConfigMaps via envFrom and
+// valueFrom are resolved, Secrets are never read. Timeouts set from a
+// Secret or the Pod's fields are returned as unresolved. A key which is
+// missing from its ConfigMap, and is not optional, is returned as a
+// message.
+func resolveEnv(namespace string, container corev1.Container, lookup configMapLookup) ([]envVar, []string) {
+	var vars []envVar
+	var problems []string
+
+	for _, from := range container.EnvFrom {
+		if from.ConfigMapRef == nil {
+			continue
+		}
+
+		data, err := lookup(namespace, from.ConfigMapRef.Name, isOptional(from.ConfigMapRef.Optional))
+		if err != nil {
+			continue
+		}
+
+		for _, key := range sortedKeys(data) {
+			vars = append(vars, envVar{
+				Name:   from.Prefix + key,
+				Value:  data[key],
+				Source: fmt.Sprintf("configmap/%s", from.ConfigMapRef.Name),
+			})
+		}
+	}
+
+	for _, env := range container.Env {
+		if env.ValueFrom == nil {
+			vars = append(vars, envVar{Name: env.Name, Value: env.Value, Source: envSourceLiteral})
+			continue
+		}
+
+		if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+			data, err := lookup(namespace, ref.Name, isOptional(ref.Optional))
+			if err != nil {
+				continue
+			}
+
+			value, ok := data[ref.Key]
+			if !ok {
+				// Kubernetes will not start the container without the key
+				if !isOptional(ref.Optional) {
+					problems = append(problems, fmt.Sprintf("configmap %s/%s has no key %s for %s on %s", namespace, ref.Name, ref.Key, env.Name, container.Name))
+				}
+				continue
+			}
+
+			vars = append(vars, envVar{
+				Name:   env.Name,
+				Value:  value,
+				Source: fmt.Sprintf("configmap/%s:%s", ref.Name, ref.Key),
+			})
+			continue
+		}
+
+		var source string
+		switch from := env.ValueFrom; {
+		case from.SecretKeyRef != nil:
+			source = fmt.Sprintf("secret/%s:%s", from.SecretKeyRef.Name, from.SecretKeyRef.Key)
+		case from.FieldRef != nil:
+			source = fmt.Sprintf("field/%s", from.FieldRef.FieldPath)
+		case from.ResourceFieldRef != nil:
+			source = fmt.Sprintf("resource/%s", from.ResourceFieldRef.Resource)
+		default:
+			continue
+		}
+		// Only the timeouts are returned, so that their rules do not
+		// assume the watchdog's default
+		if isTimeout(env.Name) {
+			vars = append(vars, envVar{Name: env.Name, Source: source, Unresolved: true})
+		}
+	}
+
+	return vars, problems
+}
+
+// isTimeout is true for a timeout of a watchdog or core component, such
+// as write_timeout or upstream_timeout
+func isTimeout(name string) bool {
+	return strings.HasSuffix(name, "_timeout")
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
@@ -0,0 +1,176 @@
+package checker
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_resolveEnv(t *testing.T) {
+	configMaps := map[string]map[string]string{
+		"openfaas-fn/timeouts": {
+			"read_timeout":  "30s",
+			"write_timeout": "30s",
+		},
+		"openfaas-fn/exec": {
+			"timeout": "25s",
+		},
+	}
+
+	lookup := func(namespace, name string, optional bool) (map[string]string, error) {
+		if data, ok := configMaps[namespace+"/"+name]; ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("configmap %s not found", name)
+	}
+
+	container := corev1.Container{
+		EnvFrom: []corev1.EnvFromSource{
+			{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "timeouts"}}},
+			{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "missing"}}},
+		},
+		Env: []corev1.EnvVar{
+			{Name: "write_timeout", Value: "10s"},
+			{Name: "exec_timeout", ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "exec"},
+					Key:                  "timeout",
+				},
+			}},
+			{Name: "token", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "token"},
+					Key:                  "token",
+				},
+			}},
+		},
+	}
+
+	vars, problems := resolveEnv("openfaas-fn", container, lookup)
+	if len(problems) > 0 {
+		t.Errorf("want no problems, got %v", problems)
+	}
+
+	got := map[string]envVar{}
+	for _, env := range vars {
+		got[env.Name] = env
+	}
+
+	want := map[string]envVar{
+		"read_timeout":  {Name: "read_timeout", Value: "30s", Source: "configmap/timeouts"},
+		"write_timeout": {Name: "write_timeout", Value: "10s", Source: "env"},
+		"exec_timeout":  {Name: "exec_timeout", Value: "25s", Source: "configmap/exec:timeout"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("want %d variables, got %d: %v", len(want), len(got), got)
+	}
+
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s want %+v, got %+v", name, w, got[name])
+		}
+	}
+}
+
+func Test_resolveEnv_MissingKey(t *testing.T) {
+	lookup := func(namespace, name string, optional bool) (map[string]string, error) {
+		return map[string]string{"read_timeout": "30s"}, nil
+	}
+
+	optional := true
+	keyRef := func(name, key string, optional *bool) corev1.EnvVar {
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "timeouts"},
+				Key:                  key,
+				Optional:             optional,
+			},
+		}}
+	}
+
+	container := corev1.Container{
+		Name: "env",
+		Env: []corev1.EnvVar{
+			keyRef("read_timeout", "read_timeout", nil),
+			keyRef("write_timeout", "write_timeout", nil),
+			keyRef("exec_timeout", "exec_timeout", &optional),
+		},
+	}
+
+	vars, problems := resolveEnv("openfaas-fn", container, lookup)
+	if len(vars) != 1 || vars[0].Name != "read_timeout" {
+		t.Errorf("want only read_timeout to be resolved, got %v", vars)
+	}
+
+	want := []string{"configmap openfaas-fn/timeouts has no key write_timeout for write_timeout on env"}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("want problems %v, got %v", want, problems)
+	}
+}
+
+func Test_resolveEnv_Unresolved(t *testing.T) {
+	lookup := func(namespace, name string, optional bool) (map[string]string, error) {
+		return nil, fmt.Errorf("configmap %s not found", name)
+	}
+
+	container := corev1.Container{
+		Env: []corev1.EnvVar{
+			{Name: "write_timeout", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "timeouts"},
+					Key:                  "write",
+				},
+			}},
+			{Name: "exec_timeout", ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.annotations['exec']"},
+			}},
+			{Name: "token", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "token"},
+					Key:                  "token",
+				},
+			}},
+		},
+	}
+
+	vars, problems := resolveEnv("openfaas-fn", container, lookup)
+	if len(problems) > 0 {
+		t.Errorf("want no problems, got %v", problems)
+	}
+
+	want := []envVar{
+		{Name: "write_timeout", Source: "secret/timeouts:write", Unresolved: true},
+		{Name: "exec_timeout", Source: "field/metadata.annotations['exec']", Unresolved: true},
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("want only the timeouts to be unresolved %+v, got %+v", want, vars)
+	}
+}
+
+func Test_Timeout_set(t *testing.T) {
+	timeout := NewTimeout()
+	timeout.set(envVar{Name: "write_timeout", Value: "30s", Source: "configmap/timeouts"})
+	timeout.set(envVar{Name: "exec_timeout", Value: "30s", Source: "configmap/timeouts"})
+
+	// Values from env override those from envFrom
+	timeout.set(envVar{Name: "write_timeout", Source: "secret/timeouts:write", Unresolved: true})
+	timeout.set(envVar{Name: "exec_timeout", Source: "secret/timeouts:exec", Unresolved: true})
+
+	if len(timeout.WriteTimeout) > 0 || !timeout.IsUnresolved("write_timeout") {
+		t.Errorf("want write_timeout to be unresolved, got %q", timeout.WriteTimeout)
+	}
+	if _, ok := timeout.Additional["exec_timeout"]; ok || !timeout.IsUnresolved("exec_timeout") {
+		t.Errorf("want exec_timeout to be unresolved, got %v", timeout.Additional)
+	}
+
+	timeout.set(envVar{Name: "write_timeout", Value: "10s", Source: "env"})
+	if timeout.WriteTimeout != "10s" || timeout.IsUnresolved("write_timeout") {
+		t.Errorf("want write_timeout 10s to be resolved, got %q", timeout.WriteTimeout)
+	}
+	if want := []string{"exec_timeout"}; !reflect.DeepEqual(timeout.Unresolved, want) {
+		t.Errorf("want unresolved %v, got %v", want, timeout.Unresolved)
+	}
+}
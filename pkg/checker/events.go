@@ -0,0 +1,141 @@
+package checker
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxEvents is the number of recurring Warning events kept for each
+// Deployment, those which recurred the most are kept
+const maxEvents = 5
+
+// EventSummary is a Warning event for an object, events with the same
+// reason for the same object are counted together
+type EventSummary struct {
+	// Kind and Name are the object the event is about, such as a Pod
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+
+	Reason string `json:"reason"`
+
+	// Message is from the most recent event
+	Message string `json:"message"`
+
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// summariseEvents groups the Warning events in a namespace by object and
+// reason, then returns the most recurring for each Deployment by name.
+// Events for objects which do not belong to a Deployment are left out.
+func summariseEvents(events []corev1.Event) map[string][]EventSummary {
+	type key struct {
+		deployment, kind, name, reason string
+	}
+
+	groups := map[key]*EventSummary{}
+	var keys []key
+
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning {
+			continue
+		}
+
+		deployment, ok := eventDeployment(event.InvolvedObject)
+		if !ok {
+			continue
+		}
+
+		k := key{deployment, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason}
+		summary, ok := groups[k]
+		if !ok {
+			summary = &EventSummary{
+				Kind:   event.InvolvedObject.Kind,
+				Name:   event.InvolvedObject.Name,
+				Reason: event.Reason,
+			}
+			groups[k] = summary
+			keys = append(keys, k)
+		}
+
+		summary.Count += eventCount(event)
+		if lastSeen := eventLastSeen(event); !lastSeen.Before(summary.LastSeen) {
+			summary.LastSeen = lastSeen
+			summary.Message = event.Message
+		}
+	}
+
+	byDeployment := map[string][]EventSummary{}
+	for _, k := range keys {
+		byDeployment[k.deployment] = append(byDeployment[k.deployment], *groups[k])
+	}
+
+	for deployment, summaries := range byDeployment {
+		sort.SliceStable(summaries, func(i, j int) bool {
+			if summaries[i].Count != summaries[j].Count {
+				return summaries[i].Count > summaries[j].Count
+			}
+			return summaries[i].LastSeen.After(summaries[j].LastSeen)
+		})
+		if len(summaries) > maxEvents {
+			summaries = summaries[:maxEvents]
+		}
+		byDeployment[deployment] = summaries
+	}
+
+	return byDeployment
+}
+
+// eventDeployment returns the Deployment which owns the object of an
+// event, from the names the Deployment controller generates for its
+// ReplicaSets and their Pods
+func eventDeployment(ref corev1.ObjectReference) (string, bool) {
+	switch ref.Kind {
+	case "Deployment":
+		return ref.Name, true
+	case "ReplicaSet":
+		return trimNameSuffix(ref.Name, 1)
+	case "Pod":
+		return trimNameSuffix(ref.Name, 2)
+	}
+	return "", false
+}
+
+// trimNameSuffix removes the last n dash-separated parts of a name
+func trimNameSuffix(name string, n int) (string, bool) {
+	for i := 0; i < n; i++ {
+		idx := strings.LastIndex(name, "-")
+		if idx <= 0 {
+			return "", false
+		}
+		name = name[:idx]
+	}
+	return name, true
+}
+
+// eventCount returns how many times an event occurred, from the series
+// of an events.k8s.io event or the deprecated count
+func eventCount(event corev1.Event) int {
+	if event.Series != nil && event.Series.Count > 0 {
+		return int(event.Series.Count)
+	}
+	if event.Count > 0 {
+		return int(event.Count)
+	}
+	return 1
+}
+
+func eventLastSeen(event corev1.Event) time.Time {
+	switch {
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
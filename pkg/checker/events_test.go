@@ -0,0 +1,71 @@
+package checker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newEvent(eventType, kind, name, reason, message string, count int32, lastSeen time.Time) corev1.Event {
+	return corev1.Event{
+		Type:           eventType,
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: name},
+		Reason:         reason,
+		Message:        message,
+		Count:          count,
+		LastTimestamp:  metav1.NewTime(lastSeen),
+	}
+}
+
+func Test_summariseEvents(t *testing.T) {
+	now := time.Now()
+
+	events := []corev1.Event{
+		newEvent("Warning", "Pod", "env-6c8b-fghij", "BackOff", "Back-off restarting failed container", 3, now.Add(-time.Minute)),
+		newEvent("Warning", "Pod", "env-6c8b-fghij", "BackOff", "Back-off restarting failed container env", 9, now),
+		newEvent("Warning", "Pod", "env-6c8b-fghij", "Unhealthy", "Readiness probe failed", 2, now),
+		newEvent("Warning", "ReplicaSet", "env-6c8b", "FailedCreate", "exceeded quota", 1, now),
+		newEvent("Normal", "Pod", "env-6c8b-fghij", "Pulled", "Successfully pulled image", 20, now),
+		newEvent("Warning", "Pod", "env-prod-7d9f-abcde", "FailedMount", "secret \"api-key\" not found", 4, now),
+		newEvent("Warning", "Node", "node-1", "NodeNotReady", "Node is not ready", 1, now),
+	}
+
+	summaries := summariseEvents(events)
+
+	env := summaries["env"]
+	if len(env) != 3 {
+		t.Fatalf("want 3 Warning events for env, got: %+v", env)
+	}
+	if env[0].Reason != "BackOff" || env[0].Count != 12 || env[0].Message != "Back-off restarting failed container env" {
+		t.Errorf("want the BackOff events counted together with the latest message, got: %+v", env[0])
+	}
+	if env[2].Kind != "ReplicaSet" || env[2].Reason != "FailedCreate" {
+		t.Errorf("want the ReplicaSet's event last, got: %+v", env[2])
+	}
+
+	if prod := summaries["env-prod"]; len(prod) != 1 || prod[0].Reason != "FailedMount" {
+		t.Errorf("want the FailedMount event for env-prod, got: %+v", prod)
+	}
+
+	if len(summaries) != 2 {
+		t.Errorf("want events only for env and env-prod, got: %v", summaries)
+	}
+}
+
+func Test_summariseEvents_KeepsMostRecurring(t *testing.T) {
+	var events []corev1.Event
+	for i := 1; i <= maxEvents+2; i++ {
+		events = append(events, newEvent("Warning", "Pod", "env-6c8b-fghij", fmt.Sprintf("Reason%d", i), "", int32(i), time.Now()))
+	}
+
+	env := summariseEvents(events)["env"]
+	if len(env) != maxEvents {
+		t.Fatalf("want %d events, got %d", maxEvents, len(env))
+	}
+	if env[0].Count != maxEvents+2 || env[maxEvents-1].Count != 3 {
+		t.Errorf("want the most recurring events first, got: %+v", env)
+	}
+}
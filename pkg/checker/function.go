@@ -0,0 +1,289 @@
+package checker
+
+import (
+	"fmt"
+	"time"
+)
+
+type Timeout struct {
+	WriteTimeout string            `json:"writeTimeout,omitempty"`
+	ReadTimeout  string            `json:"readTimeout,omitempty"`
+	Additional   map[string]string `json:"additional,omitempty"`
+
+	// Sources records where each timeout was read from by its
+	// environment variable name, such as "env" or "configmap/name:key"
+	Sources map[string]string `json:"sources,omitempty"`
+
+	// Unresolved are the timeouts which are set from a Secret or the
+	// Pod's fields, so their values are not known, their Sources say where
+	// they are set from
+	Unresolved []string `json:"unresolved,omitempty"`
+}
+
+// set sets the timeout named by an environment variable, replacing any
+// earlier value
+func (t *Timeout) set(env envVar) {
+	t.Sources[env.Name] = env.Source
+
+	unresolved := t.Unresolved[:0]
+	for _, name := range t.Unresolved {
+		if name != env.Name {
+			unresolved = append(unresolved, name)
+		}
+	}
+	t.Unresolved = unresolved
+
+	value := env.Value
+	if env.Unresolved {
+		t.Unresolved = append(t.Unresolved, env.Name)
+		value = ""
+	}
+
+	switch env.Name {
+	case "read_timeout":
+		t.ReadTimeout = value
+	case "write_timeout":
+		t.WriteTimeout = value
+	default:
+		if env.Unresolved {
+			delete(t.Additional, env.Name)
+		} else {
+			t.Additional[env.Name] = value
+		}
+	}
+	if len(t.Unresolved) == 0 {
+		t.Unresolved = nil
+	}
+}
+
+// IsUnresolved is true when the timeout is set, but from a Secret or the
+// Pod's fields which were not read
+func (t *Timeout) IsUnresolved(name string) bool {
+	if t == nil {
+		return false
+	}
+	for _, unresolved := range t.Unresolved {
+		if unresolved == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetWriteTimeout parses WriteTimeout
+func (t *Timeout) GetWriteTimeout() (time.Duration, error) {
+	return parseTimeout("write_timeout", t.WriteTimeout)
+}
+
+// GetReadTimeout parses ReadTimeout
+func (t *Timeout) GetReadTimeout() (time.Duration, error) {
+	return parseTimeout("read_timeout", t.ReadTimeout)
+}
+
+// GetAdditionalTimeout parses the timeout named by key, such as
+// exec_timeout or upstream_timeout
+func (t *Timeout) GetAdditionalTimeout(key string) (time.Duration, error) {
+	if v, ok := t.Additional[key]; ok {
+		return parseTimeout(key, v)
+	}
+	return time.Second * 0, fmt.Errorf("%s not found", key)
+}
+
+// parseErrors returns a message for each timeout which is set but can not
+// be parsed, timeouts which are not set are not an error.
+func (t *Timeout) parseErrors() []string {
+	if t == nil {
+		return nil
+	}
+
+	var messages []string
+	if len(t.ReadTimeout) > 0 {
+		if _, err := t.GetReadTimeout(); err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(t.WriteTimeout) > 0 {
+		if _, err := t.GetWriteTimeout(); err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+
+	for _, key := range sortedKeys(t.Additional) {
+		if _, err := t.GetAdditionalTimeout(key); err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	return messages
+}
+
+type FunctionResources struct {
+	Memory string `json:"memory"`
+	CPU    string `json:"cpu"`
+}
+
+func (r *FunctionResources) GetMemory() string {
+	if r.Memory == "0" {
+		return "<none>"
+	}
+	return r.Memory
+}
+
+func (r *FunctionResources) GetCpu() string {
+	if r.CPU == "0" {
+		return "<none>"
+	}
+	return r.CPU
+}
+
+type Function struct {
+	Name        string `json:"name"`
+	MaxInflight *int   `json:"maxInflight,omitempty"`
+	Replicas    int    `json:"replicas"`
+
+	// https://docs.openfaas.com/tutorials/expanded-timeouts/
+	// of-watchdog: exec_timeout
+	// classic-watchdog:
+	Timeout                *Timeout           `json:"timeout"`
+	Scaling                *Scaling           `json:"scaling,omitempty"`
+	Requests               *FunctionResources `json:"requests"`
+	Limits                 *FunctionResources `json:"limits"`
+	ReadOnlyRootFilesystem bool               `json:"readOnlyRootFilesystem"`
+
+	// Watchdog is detected from the function's environment
+	Watchdog WatchdogConfig `json:"watchdog"`
+
+	// Probes are the function container's liveness and readiness probes,
+	// it is nil when they were not collected, such as for a stack.yml
+	// where the controller adds them at deploy time
+	Probes *Probes `json:"probes,omitempty"`
+
+	// PodSecurity is the function's Pod spec evaluated against the Pod
+	// Security Standards, it is nil when there was no Pod spec to evaluate,
+	// such as for a stack.yml
+	PodSecurity *PodSecurity `json:"podSecurity,omitempty"`
+
+	// ExternalScalers are HorizontalPodAutoscalers, KEDA ScaledObjects and
+	// VerticalPodAutoscalers which target the function's Deployment, they
+	// are only collected when the OpenFaaS autoscaler is deployed
+	ExternalScalers []ExternalScaler `json:"externalScalers,omitempty"`
+
+	// Health is the state of the function's Pods, it is nil when they
+	// were not collected
+	Health *WorkloadHealth `json:"health,omitempty"`
+
+	// IgnoredRules are the rule IDs ignored through IgnoreAnnotation
+	IgnoredRules []string `json:"ignoredRules,omitempty"`
+
+	// ParseErrors are the labels and environment variables which could
+	// not be parsed when the function was collected
+	ParseErrors []string `json:"parseErrors,omitempty"`
+}
+
+// effectiveTimeout returns the timeout the watchdog applies, which is its
+// default when the timeout is not set. False is returned when the value
+// can not be parsed or is unresolved, or the default is not known.
+func (f *Function) effectiveTimeout(key string) (time.Duration, bool) {
+	var value string
+	switch key {
+	case "read_timeout":
+		value = f.Timeout.ReadTimeout
+	case "write_timeout":
+		value = f.Timeout.WriteTimeout
+	default:
+		value = f.Timeout.Additional[key]
+	}
+
+	if f.Timeout.IsUnresolved(key) {
+		return 0, false
+	}
+	if len(value) == 0 {
+		return f.Watchdog.Watchdog.defaultTimeout(key)
+	}
+
+	d, err := parseTimeout(key, value)
+	return d, err == nil
+}
+
+func (f *Function) GetMaxInflight() string {
+	if f.MaxInflight != nil {
+		return fmt.Sprintf("%d", *f.MaxInflight)
+	}
+	return "<not set>"
+}
+
+// --label com.openfaas.scale.max=10 \
+// --label com.openfaas.scale.target=100 \
+// --label com.openfaas.scale.type=cpu \
+// --label com.openfaas.scale.target-proportion=0.50 \
+// --label com.openfaas.scale.zero=true \
+// --label com.openfaas.scale.zero-duration=30m
+//
+// https://docs.openfaas.com/architecture/autoscaling/
+type Scaling struct {
+	Min          *int   `json:"min,omitempty"`
+	Max          *int   `json:"max,omitempty"`
+	Type         string `json:"type,omitempty"`
+	Target       string `json:"target,omitempty"`
+	Proportion   string `json:"proportion,omitempty"`
+	Zero         string `json:"zero,omitempty"`
+	ZeroDuration string `json:"zeroDuration,omitempty"`
+}
+
+func (s *Scaling) GetMax() string {
+	if s.Max != nil {
+		return fmt.Sprintf("%d", *s.Max)
+	}
+	return "<not set>"
+}
+
+func (s *Scaling) GetMin() string {
+	if s.Min != nil {
+		return fmt.Sprintf("%d", *s.Min)
+	}
+	return "<not set>"
+}
+
+func (s *Scaling) GetType() string {
+	if len(s.Type) == 0 {
+		return "<not set>"
+	}
+	return s.Type
+}
+
+func (s *Scaling) GetTarget() string {
+	if len(s.Target) == 0 {
+		return "<not set>"
+	}
+	return s.Target
+}
+
+func (s *Scaling) GetProportion() string {
+	if len(s.Proportion) == 0 {
+		return "<not set>"
+	}
+	return s.Proportion
+}
+
+func (s *Scaling) GetZero() string {
+	if len(s.Zero) == 0 {
+		return "<not set>"
+	}
+	return s.Zero
+}
+
+func (s *Scaling) GetZeroDuration() string {
+	if len(s.ZeroDuration) == 0 {
+		return "<not set>"
+	}
+	return s.ZeroDuration
+}
+
+// NewTimeout returns an empty Timeout
+func NewTimeout() *Timeout {
+	return &Timeout{
+		Additional:   make(map[string]string),
+		Sources:      make(map[string]string),
+		WriteTimeout: "",
+		ReadTimeout:  "",
+	}
+}
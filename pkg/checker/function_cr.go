@@ -0,0 +1,233 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// FunctionsResource is the Function custom resource which the operator
+// reconciles into a Deployment.
+var FunctionsResource = schema.GroupVersionResource{
+	Group:    "openfaas.com",
+	Version:  "v1",
+	Resource: "functions",
+}
+
+// FunctionCR is the spec of a Function custom resource as it was written,
+// which may not have been reconciled into a Deployment yet.
+type FunctionCR struct {
+	// Name is metadata.name of the custom resource
+	Name string `json:"name"`
+
+	// FunctionName is spec.name, which the operator uses to name the
+	// Deployment
+	FunctionName string             `json:"functionName"`
+	Image        string             `json:"image"`
+	Labels       map[string]string  `json:"labels,omitempty"`
+	Annotations  map[string]string  `json:"annotations,omitempty"`
+	Secrets      []string           `json:"secrets,omitempty"`
+	Limits       *FunctionResources `json:"limits,omitempty"`
+	Requests     *FunctionResources `json:"requests,omitempty"`
+}
+
+// DeploymentName is the name of the Deployment which the operator creates
+// for the function
+func (f *FunctionCR) DeploymentName() string {
+	if len(f.FunctionName) > 0 {
+		return f.FunctionName
+	}
+	return f.Name
+}
+
+// functionObject is the subset of openfaas.com/v1 Function read by the
+// checker, so that the operator's types do not need to be imported.
+type functionObject struct {
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Name        string             `json:"name"`
+		Image       string             `json:"image"`
+		Labels      *map[string]string `json:"labels,omitempty"`
+		Annotations *map[string]string `json:"annotations,omitempty"`
+		Secrets     []string           `json:"secrets,omitempty"`
+		Limits      *FunctionResources `json:"limits,omitempty"`
+		Requests    *FunctionResources `json:"requests,omitempty"`
+	} `json:"spec"`
+}
+
+// readFunctionCRs converts Function custom resources, those which can not
+// be converted are skipped and returned as messages.
+func readFunctionCRs(items []unstructured.Unstructured) ([]FunctionCR, []string) {
+	functions := []FunctionCR{}
+	var problems []string
+
+	for _, item := range items {
+		var obj functionObject
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.UnstructuredContent(), &obj); err != nil {
+			problems = append(problems, fmt.Sprintf("could not read function CR %s.%s: %s", item.GetName(), item.GetNamespace(), err))
+			continue
+		}
+
+		fn := FunctionCR{
+			Name:         obj.Metadata.Name,
+			FunctionName: obj.Spec.Name,
+			Image:        obj.Spec.Image,
+			Secrets:      obj.Spec.Secrets,
+			Limits:       obj.Spec.Limits,
+			Requests:     obj.Spec.Requests,
+		}
+		if obj.Spec.Labels != nil {
+			fn.Labels = *obj.Spec.Labels
+		}
+		if obj.Spec.Annotations != nil {
+			fn.Annotations = *obj.Spec.Annotations
+		}
+
+		functions = append(functions, fn)
+	}
+
+	return functions, problems
+}
+
+// validate returns the problems in the spec which would stop the operator
+// from creating a working Deployment.
+func (f *FunctionCR) validate() []string {
+	var messages []string
+
+	if len(f.FunctionName) == 0 {
+		messages = append(messages, "spec.name is not set")
+	} else if f.FunctionName != f.Name {
+		messages = append(messages, fmt.Sprintf("spec.name '%s' does not match metadata.name", f.FunctionName))
+	}
+
+	if len(f.Image) == 0 {
+		messages = append(messages, "spec.image is not set")
+	}
+
+	for _, key := range sortedKeys(f.Labels) {
+		value := f.Labels[key]
+		for _, msg := range validation.IsQualifiedName(key) {
+			messages = append(messages, fmt.Sprintf("label key '%s' is invalid: %s", key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			messages = append(messages, fmt.Sprintf("label %s value '%s' is invalid: %s", key, value, msg))
+		}
+	}
+	messages = append(messages, validateScalingLabels(f.Labels)...)
+
+	for _, key := range sortedKeys(f.Annotations) {
+		for _, msg := range validation.IsQualifiedName(key) {
+			messages = append(messages, fmt.Sprintf("annotation key '%s' is invalid: %s", key, msg))
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, secret := range f.Secrets {
+		for _, msg := range validation.IsDNS1123Subdomain(secret) {
+			messages = append(messages, fmt.Sprintf("secret name '%s' is invalid: %s", secret, msg))
+		}
+		if seen[secret] {
+			messages = append(messages, fmt.Sprintf("secret %s is listed more than once", secret))
+		}
+		seen[secret] = true
+	}
+
+	messages = append(messages, validateResources(f.Requests, f.Limits)...)
+
+	return messages
+}
+
+func validateScalingLabels(labels map[string]string) []string {
+	var messages []string
+
+	ints := map[string]int{}
+	for _, key := range []string{"com.openfaas.scale.min", "com.openfaas.scale.max"} {
+		value, ok := labels[key]
+		if !ok {
+			continue
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("label %s '%s' is not a whole number", key, value))
+			continue
+		}
+		ints[key] = v
+	}
+
+	min, hasMin := ints["com.openfaas.scale.min"]
+	max, hasMax := ints["com.openfaas.scale.max"]
+	if hasMin && hasMax && min > max {
+		messages = append(messages, fmt.Sprintf("label com.openfaas.scale.min (%d) is greater than com.openfaas.scale.max (%d)", min, max))
+	}
+
+	if value, ok := labels["com.openfaas.scale.zero"]; ok {
+		if _, err := strconv.ParseBool(value); err != nil {
+			messages = append(messages, fmt.Sprintf("label com.openfaas.scale.zero '%s' is not true or false", value))
+		}
+	}
+
+	if value, ok := labels["com.openfaas.scale.zero-duration"]; ok {
+		if _, err := time.ParseDuration(value); err != nil {
+			messages = append(messages, fmt.Sprintf("label com.openfaas.scale.zero-duration '%s' is not a duration", value))
+		}
+	}
+
+	return messages
+}
+
+func validateResources(requests, limits *FunctionResources) []string {
+	var messages []string
+
+	parse := func(kind, name, value string) (resource.Quantity, bool) {
+		if len(value) == 0 {
+			return resource.Quantity{}, false
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("%s %s '%s' is not a valid quantity", kind, name, value))
+			return resource.Quantity{}, false
+		}
+		return q, true
+	}
+
+	var reqMemory, reqCPU, limMemory, limCPU string
+	if requests != nil {
+		reqMemory, reqCPU = requests.Memory, requests.CPU
+	}
+	if limits != nil {
+		limMemory, limCPU = limits.Memory, limits.CPU
+	}
+
+	for _, r := range []struct {
+		name       string
+		req, limit string
+	}{
+		{"memory", reqMemory, limMemory},
+		{"cpu", reqCPU, limCPU},
+	} {
+		req, hasReq := parse("requests", r.name, r.req)
+		limit, hasLimit := parse("limits", r.name, r.limit)
+		if hasReq && hasLimit && req.Cmp(limit) > 0 {
+			messages = append(messages, fmt.Sprintf("requests %s (%s) is greater than limits %s (%s)", r.name, r.req, r.name, r.limit))
+		}
+	}
+
+	return messages
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
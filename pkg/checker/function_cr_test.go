@@ -0,0 +1,79 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_FunctionCR_validate(t *testing.T) {
+	cases := []struct {
+		name string
+		fn   FunctionCR
+		want []string
+	}{
+		{
+			name: "valid",
+			fn: FunctionCR{
+				Name:         "env",
+				FunctionName: "env",
+				Image:        "ghcr.io/openfaas/alpine:latest",
+				Labels:       map[string]string{"com.openfaas.scale.min": "1", "com.openfaas.scale.max": "5"},
+				Secrets:      []string{"api-key"},
+				Requests:     &FunctionResources{Memory: "64Mi"},
+				Limits:       &FunctionResources{Memory: "128Mi"},
+			},
+		},
+		{
+			name: "name and image",
+			fn:   FunctionCR{Name: "env", FunctionName: "env2"},
+			want: []string{"spec.name 'env2' does not match metadata.name", "spec.image is not set"},
+		},
+		{
+			name: "labels",
+			fn: FunctionCR{
+				Name:         "env",
+				FunctionName: "env",
+				Image:        "alpine",
+				Labels: map[string]string{
+					"com.openfaas.scale.min":  "5",
+					"com.openfaas.scale.max":  "2",
+					"com.openfaas.scale.zero": "yes",
+				},
+			},
+			want: []string{
+				"label com.openfaas.scale.min (5) is greater than com.openfaas.scale.max (2)",
+				"label com.openfaas.scale.zero 'yes' is not true or false",
+			},
+		},
+		{
+			name: "secrets and resources",
+			fn: FunctionCR{
+				Name:         "env",
+				FunctionName: "env",
+				Image:        "alpine",
+				Secrets:      []string{"API_KEY", "db", "db"},
+				Requests:     &FunctionResources{Memory: "256Mi", CPU: "lots"},
+				Limits:       &FunctionResources{Memory: "128Mi"},
+			},
+			want: []string{
+				"secret name 'API_KEY' is invalid",
+				"secret db is listed more than once",
+				"requests memory (256Mi) is greater than limits memory (128Mi)",
+				"requests cpu 'lots' is not a valid quantity",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		got := c.fn.validate()
+		if len(got) != len(c.want) {
+			t.Errorf("%s: want %d problems, got %d: %v", c.name, len(c.want), len(got), got)
+			continue
+		}
+		for i, want := range c.want {
+			if !strings.HasPrefix(got[i], want) {
+				t.Errorf("%s: want %q, got %q", c.name, want, got[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package checker
+
+import (
+	"context"
+	"sort"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// haComponents are the core components which are scaled out to be Highly
+// Available
+var haComponents = []string{"gateway", "queue-worker"}
+
+// zoneLabels are the labels for a node's zone, the deprecated label is
+// still set by some providers
+var zoneLabels = []string{
+	"topology.kubernetes.io/zone",
+	"failure-domain.beta.kubernetes.io/zone",
+}
+
+// HighAvailability is how the replicas of a core component are protected
+// from disruption and spread across the cluster.
+type HighAvailability struct {
+	// PodDisruptionBudgets are the names of the budgets which select the
+	// component's Pods, it is nil when they could not be listed
+	PodDisruptionBudgets []string `json:"podDisruptionBudgets"`
+
+	// AntiAffinity is true when the Pods have a podAntiAffinity rule
+	AntiAffinity bool `json:"antiAffinity"`
+
+	// TopologySpread is true when the Pods have topologySpreadConstraints
+	TopologySpread bool `json:"topologySpread"`
+
+	// Running is the number of Pods which are running, Nodes and Zones are
+	// how many nodes and zones they are in, which are 0 when not known
+	Running int `json:"running"`
+	Nodes   int `json:"nodes"`
+	Zones   int `json:"zones"`
+
+	// ClusterZones is how many zones the cluster's nodes are in
+	ClusterZones int `json:"clusterZones"`
+}
+
+// collectHA reads the PodDisruptionBudgets, scheduling rules and placement
+// of each component in haComponents. It needs the Pods, so is run after
+// the health of the core components was collected.
+func (c *Collector) collectHA(ctx context.Context, snapshot *ClusterSnapshot, deps []v1.Deployment) {
+	budgets, err := c.client.PolicyV1().PodDisruptionBudgets(c.coreNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		// policy/v1 is not served before Kubernetes 1.21. The client
+		// returns an empty list with the error, which would read as no
+		// budgets rather than unknown.
+		if !apierrors.IsNotFound(err) {
+			snapshot.addError("could not list poddisruptionbudgets in namespace %s: %s", c.coreNamespace, err)
+		}
+		budgets = nil
+	}
+
+	nodeZones := map[string]string{}
+	clusterZones := map[string]bool{}
+	nodes, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		snapshot.addError("could not list nodes: %s", err)
+	} else {
+		for _, node := range nodes.Items {
+			for _, label := range zoneLabels {
+				if zone, ok := node.Labels[label]; ok {
+					nodeZones[node.Name] = zone
+					clusterZones[zone] = true
+					break
+				}
+			}
+		}
+	}
+
+	for _, dep := range deps {
+		component := componentName(dep)
+		if !contains(haComponents, component) {
+			continue
+		}
+
+		ha := &HighAvailability{
+			ClusterZones: len(clusterZones),
+		}
+
+		if budgets != nil {
+			pdbs := []string{}
+			for _, pdb := range budgets.Items {
+				selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+				if err != nil {
+					continue
+				}
+				if selector.Matches(labels.Set(dep.Spec.Template.Labels)) {
+					pdbs = append(pdbs, pdb.Name)
+				}
+			}
+			sort.Strings(pdbs)
+			ha.PodDisruptionBudgets = pdbs
+		}
+
+		spec := dep.Spec.Template.Spec
+		if affinity := spec.Affinity; affinity != nil && affinity.PodAntiAffinity != nil {
+			anti := affinity.PodAntiAffinity
+			ha.AntiAffinity = len(anti.RequiredDuringSchedulingIgnoredDuringExecution) > 0 ||
+				len(anti.PreferredDuringSchedulingIgnoredDuringExecution) > 0
+		}
+		ha.TopologySpread = len(spec.TopologySpreadConstraints) > 0
+
+		if health := snapshot.CoreHealth[dep.Name]; health != nil {
+			ha.Running, ha.Nodes, ha.Zones = placement(health.Pods, nodeZones)
+		}
+
+		if snapshot.HA == nil {
+			snapshot.HA = map[string]*HighAvailability{}
+		}
+		snapshot.HA[component] = ha
+	}
+}
+
+// placement returns how many Pods are running, and how many nodes and
+// zones they are in
+func placement(pods []PodHealth, nodeZones map[string]string) (int, int, int) {
+	running := 0
+	nodes := map[string]bool{}
+	zones := map[string]bool{}
+	for _, pod := range pods {
+		if pod.Phase != string(corev1.PodRunning) || len(pod.Node) == 0 {
+			continue
+		}
+		running++
+		nodes[pod.Node] = true
+		if zone, ok := nodeZones[pod.Node]; ok {
+			zones[zone] = true
+		}
+	}
+	return running, len(nodes), len(zones)
+}
+
+// highlyAvailable is true when a component has at least min replicas, and
+// where it was collected, a PodDisruptionBudget and Pods running on more
+// than one node. As for the OF-HA rules, these are only required of a
+// component with more than one replica.
+func (s *ClusterSnapshot) highlyAvailable(name string, replicas, min int) bool {
+	if replicas < min {
+		return false
+	}
+	if replicas <= 1 {
+		return true
+	}
+
+	ha, ok := s.HA[name]
+	if !ok {
+		return true
+	}
+	if ha.PodDisruptionBudgets != nil && len(ha.PodDisruptionBudgets) == 0 {
+		return false
+	}
+	return ha.Nodes != 1
+}
+
+// deployment returns the name of a core component's Deployment, which is
+// the component's name unless the gateway was found under another name
+func (s *ClusterSnapshot) deployment(component string) string {
+	if component == "gateway" && len(s.Components.Gateway.Deployment) > 0 {
+		return s.Components.Gateway.Deployment
+	}
+	return component
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
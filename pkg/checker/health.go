@@ -0,0 +1,153 @@
+package checker
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// failingReasons are the reasons for a waiting container which will not
+// start without a change, as opposed to ContainerCreating
+var failingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+}
+
+// WorkloadHealth is the state of the Pods of a Deployment at the time it
+// was collected, rather than its spec.
+type WorkloadHealth struct {
+	// Replicas is the number of replicas the Deployment wants
+	Replicas int `json:"replicas"`
+
+	// Available is the Deployment's availableReplicas, it is nil when the
+	// Deployment has no status, such as when it was exported without one
+	Available *int `json:"available,omitempty"`
+
+	// Pods are the running and pending Pods of the Deployment
+	Pods []PodHealth `json:"pods,omitempty"`
+
+	// Events are the most recurring Warning events for the Deployment,
+	// its ReplicaSets and its Pods
+	Events []EventSummary `json:"events,omitempty"`
+}
+
+// PodHealth is the state of a single Pod
+type PodHealth struct {
+	Name  string `json:"name"`
+	Phase string `json:"phase"`
+
+	// Node is the node the Pod was scheduled to
+	Node string `json:"node,omitempty"`
+
+	// Unschedulable is the scheduler's reason and message when a Pending
+	// Pod could not be placed on a node
+	Unschedulable string `json:"unschedulable,omitempty"`
+
+	Containers []ContainerHealth `json:"containers,omitempty"`
+}
+
+// ContainerHealth is the state of a container within a Pod
+type ContainerHealth struct {
+	Name     string `json:"name"`
+	Restarts int    `json:"restarts"`
+
+	// Waiting is the reason a container is not running such as
+	// CrashLoopBackOff
+	Waiting string `json:"waiting,omitempty"`
+
+	// LastTermination is the reason the container last exited such as
+	// OOMKilled
+	LastTermination string `json:"lastTermination,omitempty"`
+}
+
+// failing returns the containers which are waiting for a reason which
+// will not resolve itself
+func (p PodHealth) failing() []ContainerHealth {
+	var failing []ContainerHealth
+	for _, container := range p.Containers {
+		if failingReasons[container.Waiting] {
+			failing = append(failing, container)
+		}
+	}
+	return failing
+}
+
+// readHealth returns the health of a Deployment from its status and the
+// Pods which match its selector. Pods which have completed or failed are
+// left out, since they are no longer part of the Deployment.
+func readHealth(dep v1.Deployment, pods []corev1.Pod) *WorkloadHealth {
+	health := &WorkloadHealth{
+		Replicas: replicas(dep),
+	}
+
+	// The Deployment controller sets observedGeneration whenever it
+	// updates the status, so a status of zero has not been reported
+	if dep.Status.ObservedGeneration > 0 {
+		available := int(dep.Status.AvailableReplicas)
+		health.Available = &available
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(dep.Spec.Selector)
+	if err != nil || selector.Empty() {
+		return health
+	}
+
+	for _, pod := range pods {
+		if pod.Namespace != dep.Namespace || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		health.Pods = append(health.Pods, readPodHealth(pod))
+	}
+
+	sort.Slice(health.Pods, func(i, j int) bool {
+		return health.Pods[i].Name < health.Pods[j].Name
+	})
+
+	return health
+}
+
+func readPodHealth(pod corev1.Pod) PodHealth {
+	p := PodHealth{
+		Name:  pod.Name,
+		Phase: string(pod.Status.Phase),
+		Node:  pod.Spec.NodeName,
+	}
+
+	if pod.Status.Phase == corev1.PodPending {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+				p.Unschedulable = condition.Reason
+				if len(condition.Message) > 0 {
+					p.Unschedulable += ": " + condition.Message
+				}
+			}
+		}
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		container := ContainerHealth{
+			Name:     status.Name,
+			Restarts: int(status.RestartCount),
+		}
+		if status.State.Waiting != nil {
+			container.Waiting = status.State.Waiting.Reason
+		}
+		if status.LastTerminationState.Terminated != nil {
+			container.LastTermination = status.LastTerminationState.Terminated.Reason
+		}
+		p.Containers = append(p.Containers, container)
+	}
+
+	return p
+}
@@ -0,0 +1,54 @@
+package checker
+
+import "strings"
+
+// IgnoreAnnotation is set on a function's Deployment or on a namespace
+// to suppress findings for a comma-separated list of rule IDs, e.g.
+// "OF-FN-004,OF-FN-007". Setting it on the OpenFaaS core namespace
+// suppresses findings for the core components.
+const IgnoreAnnotation = "config-checker.openfaas.com/ignore"
+
+// parseIgnoreAnnotation returns the rule IDs listed in the annotation
+func parseIgnoreAnnotation(annotations map[string]string) []string {
+	value, ok := annotations[IgnoreAnnotation]
+	if !ok {
+		return nil
+	}
+
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		id = strings.ToUpper(strings.TrimSpace(id))
+		if len(id) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func containsRule(ids []string, ruleID string) bool {
+	for _, id := range ids {
+		if id == ruleID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ClusterSnapshot) ignored(namespace, ruleID string) bool {
+	return containsRule(s.IgnoredRules[namespace], ruleID)
+}
+
+func (f *Function) ignored(ruleID string) bool {
+	return containsRule(f.IgnoredRules, ruleID)
+}
+
+// notIgnoring returns the functions which do not ignore ruleID
+func notIgnoring(functions []Function, ruleID string) []Function {
+	var filtered []Function
+	for _, fn := range functions {
+		if !fn.ignored(ruleID) {
+			filtered = append(filtered, fn)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,27 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseIgnoreAnnotation(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		want        []string
+	}{
+		{"no annotation", map[string]string{}, nil},
+		{"single", map[string]string{IgnoreAnnotation: "OF-FN-004"}, []string{"OF-FN-004"}},
+		{"list with spaces", map[string]string{IgnoreAnnotation: " OF-FN-004, of-fn-007,"}, []string{"OF-FN-004", "OF-FN-007"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := parseIgnoreAnnotation(c.annotations)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
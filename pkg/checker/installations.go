@@ -0,0 +1,144 @@
+package checker
+
+import (
+	"context"
+	"sort"
+
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// coreDeployments are the names of the core components which are read
+// even when they don't have the chart's app=openfaas label
+var coreDeployments = []string{"gateway", "queue-worker", "autoscaler", "dashboard", "nats"}
+
+// DiscoverInstallations returns the namespaces of each OpenFaaS
+// installation in the cluster, found by their gateway Deployments.
+func DiscoverInstallations(ctx context.Context, client kubernetes.Interface) ([]string, error) {
+	deps, err := client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var namespaces []string
+	for _, dep := range deps.Items {
+		if isGateway(dep) && !seen[dep.Namespace] {
+			seen[dep.Namespace] = true
+			namespaces = append(namespaces, dep.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	return namespaces, nil
+}
+
+// isGateway returns true for the gateway's Deployment, whose Pod runs the
+// gateway alongside faas-netes or the operator
+func isGateway(dep v1.Deployment) bool {
+	gateway, controller := false, false
+	for _, container := range dep.Spec.Template.Spec.Containers {
+		switch container.Name {
+		case "gateway":
+			gateway = true
+		case "faas-netes", "operator":
+			controller = true
+		}
+	}
+	return gateway && controller
+}
+
+// componentName returns the name of the core component a Deployment runs,
+// which for the gateway may differ from the Deployment's name
+func componentName(dep v1.Deployment) string {
+	if isGateway(dep) {
+		return "gateway"
+	}
+	return dep.Name
+}
+
+// isCoreDeployment returns true for a Deployment in an installation's
+// namespace which is part of OpenFaaS, rather than another workload
+func isCoreDeployment(dep v1.Deployment) bool {
+	return dep.Labels["app"] == "openfaas" || isGateway(dep) || contains(coreDeployments, dep.Name)
+}
+
+// AssignNamespaces reports a function namespace which is used by more than
+// one installation under only one of them, since each controller with
+// cluster_role selects every namespace marked for functions. The namespace
+// is kept by the installation whose own function namespace it is, then by
+// the first one whose controller has a RoleBinding in it, then by the
+// first one. The others record it in SharedNamespaces.
+func AssignNamespaces(snapshots []*ClusterSnapshot) {
+	users := map[string][]*ClusterSnapshot{}
+	for _, s := range snapshots {
+		for _, namespace := range s.FunctionNamespaces {
+			users[namespace] = append(users[namespace], s)
+		}
+	}
+
+	for namespace, shared := range users {
+		if len(shared) < 2 {
+			continue
+		}
+
+		owner := shared[0]
+		for _, s := range shared {
+			if s.UnboundNamespaces != nil && !contains(s.UnboundNamespaces, namespace) {
+				owner = s
+				break
+			}
+		}
+		for _, s := range shared {
+			if s.ownFunctionNamespace() == namespace {
+				owner = s
+				break
+			}
+		}
+
+		for _, s := range shared {
+			if s != owner {
+				s.releaseNamespace(namespace, owner.CoreNamespace)
+			}
+		}
+	}
+}
+
+// ownFunctionNamespace is the controller's function_namespace, which is
+// openfaas-fn when it is not set
+func (s *ClusterSnapshot) ownFunctionNamespace() string {
+	if len(s.Components.Controller.FunctionNamespace) > 0 {
+		return s.Components.Controller.FunctionNamespace
+	}
+	return defaultFunctionNamespace
+}
+
+// releaseNamespace removes a function namespace which is reported under
+// the installation in owner
+func (s *ClusterSnapshot) releaseNamespace(namespace, owner string) {
+	s.FunctionNamespaces = without(s.FunctionNamespaces, namespace)
+	s.UnboundNamespaces = without(s.UnboundNamespaces, namespace)
+	delete(s.Functions, namespace)
+	delete(s.OtherWorkloads, namespace)
+	delete(s.UnreadFunctions, namespace)
+	delete(s.FunctionCRs, namespace)
+
+	if s.SharedNamespaces == nil {
+		s.SharedNamespaces = map[string]string{}
+	}
+	s.SharedNamespaces[namespace] = owner
+}
+
+func without(values []string, value string) []string {
+	if values == nil {
+		return nil
+	}
+	kept := []string{}
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,152 @@
+package checker
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_DiscoverInstallations(t *testing.T) {
+	gateway := corev1.Container{Name: "gateway", Image: "ghcr.io/openfaas/gateway:0.27.0"}
+
+	client := fake.NewSimpleClientset(
+		newDeployment("openfaas", "gateway", 1, nil,
+			gateway, corev1.Container{Name: "faas-netes", Image: "ghcr.io/openfaas/faas-netes:0.17.0"}),
+		newDeployment("team-b", "openfaas-gateway", 1, nil,
+			gateway, corev1.Container{Name: "operator", Image: "ghcr.io/openfaasltd/faas-netes:0.5.0"}),
+		// Not a gateway without the controller alongside it
+		newDeployment("ingress", "gateway", 1, nil,
+			corev1.Container{Name: "gateway", Image: "envoyproxy/envoy:v1.26.0"}),
+	)
+
+	namespaces, err := DiscoverInstallations(context.Background(), client)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := []string{"openfaas", "team-b"}; !reflect.DeepEqual(namespaces, want) {
+		t.Errorf("want installations %v, got %v", want, namespaces)
+	}
+}
+
+func Test_Collect_CustomNamespaces(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newNamespace("team-b", nil),
+		newNamespace("team-b-fn", nil),
+		newDeployment("team-b", "gateway", 1, map[string]string{"app": "gateway"},
+			corev1.Container{
+				Name:  "gateway",
+				Image: "ghcr.io/openfaas/gateway:0.27.0",
+				Env:   env("upstream_timeout", "60s"),
+			},
+			corev1.Container{
+				Name:  "faas-netes",
+				Image: "ghcr.io/openfaas/faas-netes:0.17.0",
+				Env:   env("function_namespace", "team-b-fn"),
+			}),
+		newDeployment("team-b", "redis", 1, map[string]string{"app": "redis"},
+			corev1.Container{Name: "redis", Image: "redis:7"}),
+		newDeployment("team-b-fn", "hello", 1, map[string]string{"faas_function": "hello"},
+			corev1.Container{Name: "hello", Image: "ghcr.io/openfaas/alpine:latest"}),
+	)
+
+	snapshot, err := NewCollector(client, "team-b").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if snapshot.Components.Gateway.Image != "ghcr.io/openfaas/gateway:0.27.0" {
+		t.Errorf("want the gateway to be read without the app=openfaas label, got %+v", snapshot.Components.Gateway)
+	}
+	if _, ok := snapshot.CoreHealth["redis"]; ok {
+		t.Errorf("want other workloads in the core namespace to be skipped")
+	}
+
+	if want := []string{"team-b-fn"}; !reflect.DeepEqual(snapshot.FunctionNamespaces, want) {
+		t.Errorf("want function namespaces %v, got %v", want, snapshot.FunctionNamespaces)
+	}
+	if functions := snapshot.Functions["team-b-fn"]; len(functions) != 1 || functions[0].Name != "hello" {
+		t.Errorf("want the hello function, got %+v", functions)
+	}
+}
+
+func Test_Collect_RenamedGateway(t *testing.T) {
+	labels := map[string]string{"app": "openfaas-gateway"}
+	gateway := newDeployment("openfaas", "openfaas-gateway", 3, labels,
+		corev1.Container{Name: "gateway", Image: "ghcr.io/openfaas/gateway:0.27.0", Env: env("upstream_timeout", "60s")},
+		corev1.Container{Name: "faas-netes", Image: "ghcr.io/openfaas/faas-netes:0.17.0"})
+	gateway.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+
+	objects := []runtime.Object{
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		newNode("node-1", "zone-a"),
+		gateway,
+	}
+	for _, name := range []string{"openfaas-gateway-1", "openfaas-gateway-2", "openfaas-gateway-3"} {
+		pod := newPod("openfaas", name, labels, corev1.PodRunning)
+		pod.Spec.NodeName = "node-1"
+		objects = append(objects, pod)
+	}
+
+	snapshot, err := NewCollector(fake.NewSimpleClientset(objects...), "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := snapshot.Components.Gateway.Deployment; got != "openfaas-gateway" {
+		t.Errorf("want the gateway's Deployment to be recorded, got %q", got)
+	}
+	if ha := snapshot.HA["gateway"]; ha == nil || ha.Running != 3 || ha.Nodes != 1 {
+		t.Errorf("want the gateway's placement to be collected, got %+v", ha)
+	}
+	if !hasFinding(Analyze(snapshot, DefaultConfig()), "OF-HA-003", "gateway has 3 running replicas, but they are all on the same node") {
+		t.Errorf("want an HA finding for the renamed gateway")
+	}
+}
+
+func Test_AssignNamespaces(t *testing.T) {
+	newInstallation := func(core, own string, namespaces []string, unbound []string) *ClusterSnapshot {
+		s := newSnapshot()
+		s.CoreNamespace = core
+		s.Components.Controller.FunctionNamespace = own
+		s.FunctionNamespaces = namespaces
+		s.UnboundNamespaces = unbound
+		for _, namespace := range namespaces {
+			s.Functions[namespace] = []Function{newFunction("env")}
+		}
+		return s
+	}
+
+	// The controller in openfaas has no RoleBinding in shared-fn
+	a := newInstallation("openfaas", "", []string{"openfaas-fn", "shared-fn", "team-b-fn"}, []string{"shared-fn"})
+	b := newInstallation("team-b", "team-b-fn", []string{"openfaas-fn", "shared-fn", "team-b-fn"}, []string{})
+
+	AssignNamespaces([]*ClusterSnapshot{a, b})
+
+	if want := []string{"openfaas-fn"}; !reflect.DeepEqual(a.FunctionNamespaces, want) {
+		t.Errorf("want openfaas to keep %v, got %v", want, a.FunctionNamespaces)
+	}
+	if want := []string{"shared-fn", "team-b-fn"}; !reflect.DeepEqual(b.FunctionNamespaces, want) {
+		t.Errorf("want team-b to keep %v, got %v", want, b.FunctionNamespaces)
+	}
+	if _, ok := a.Functions["shared-fn"]; ok || len(a.UnboundNamespaces) > 0 {
+		t.Errorf("want shared-fn to be removed from openfaas, got %v %v", a.Functions, a.UnboundNamespaces)
+	}
+	if a.TotalFunctions()+b.TotalFunctions() != 3 {
+		t.Errorf("want each function to be counted once, got %d and %d", a.TotalFunctions(), b.TotalFunctions())
+	}
+
+	findings := Analyze(a, DefaultConfig())
+	if !hasFinding(findings, "OF-NS-004", "namespace shared-fn is also used by the installation in team-b") {
+		t.Errorf("want OF-NS-004 for shared-fn, got: %v", findings)
+	}
+	if !hasFinding(findings, "OF-NS-004", "namespace team-b-fn is also used by the installation in team-b") {
+		t.Errorf("want OF-NS-004 for team-b-fn, got: %v", findings)
+	}
+}
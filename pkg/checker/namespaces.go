@@ -0,0 +1,168 @@
+package checker
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// namespaceKey is the annotation or label which marks a namespace for
+// functions, when set to "1"
+const namespaceKey = "openfaas"
+
+// isMarkedForFunctions is true when a namespace is annotated or labelled
+// with openfaas: "1", as the controller requires in cluster_role mode
+func isMarkedForFunctions(ns corev1.Namespace) bool {
+	return ns.Annotations[namespaceKey] == "1" || ns.Labels[namespaceKey] == "1"
+}
+
+// selectFunctionNamespaces returns the namespaces the controller uses for
+// functions. Its own function_namespace is always used, and the namespaces
+// marked for functions are only used with cluster_role. When the controller
+// was not found, the marked namespaces are used as they may still have
+// functions. When listed is false, the namespaces could not be listed and
+// the controller's own namespace is assumed to exist.
+func selectFunctionNamespaces(controller Controller, namespaces []corev1.Namespace, listed bool) (selected, missing, unselected []string) {
+	own := defaultFunctionNamespace
+	if len(controller.FunctionNamespace) > 0 {
+		own = controller.FunctionNamespace
+	}
+
+	selected = []string{}
+	found := false
+	for _, ns := range namespaces {
+		if ns.Name == own {
+			found = true
+			continue
+		}
+		if !isMarkedForFunctions(ns) {
+			continue
+		}
+		if controller.ClusterRole || len(controller.Mode) == 0 {
+			selected = append(selected, ns.Name)
+		} else {
+			unselected = append(unselected, ns.Name)
+		}
+	}
+
+	if found || !listed {
+		selected = append(selected, own)
+	} else {
+		missing = append(missing, own)
+	}
+
+	sort.Strings(selected)
+	sort.Strings(unselected)
+	return selected, missing, unselected
+}
+
+// collectBindings finds the function namespaces which have no RoleBinding
+// which allows the controller's ServiceAccount to create Deployments. A
+// ClusterRoleBinding which allows it covers every namespace. The check is
+// skipped when the bindings were not collected, and a namespace whose
+// bindings or roles could not be read is left out.
+func (c *Collector) collectBindings(ctx context.Context, snapshot *ClusterSnapshot, namespaces []string) {
+	account := snapshot.Components.Controller.ServiceAccount
+
+	clusterBindings, err := c.client.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			snapshot.addError("could not list clusterrolebindings: %s", err)
+		}
+		return
+	}
+
+	// Roles are read at most once, a role which can not be read is recorded
+	// as an error and treated as allowing access, so that it is not flagged
+	roles := map[string]bool{}
+	createsDeploymentsIn := func(namespace string, ref rbacv1.RoleRef) bool {
+		key := ref.Kind + "/" + ref.Name
+		if ref.Kind == "Role" {
+			key = namespace + "/" + key
+		}
+		if allowed, ok := roles[key]; ok {
+			return allowed
+		}
+
+		var rules []rbacv1.PolicyRule
+		switch ref.Kind {
+		case "ClusterRole":
+			role, err := c.client.RbacV1().ClusterRoles().Get(ctx, ref.Name, metav1.GetOptions{})
+			if err != nil {
+				snapshot.addError("could not read clusterrole %s: %s", ref.Name, err)
+				roles[key] = true
+				return true
+			}
+			rules = role.Rules
+		case "Role":
+			role, err := c.client.RbacV1().Roles(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+			if err != nil {
+				snapshot.addError("could not read role %s.%s: %s", ref.Name, namespace, err)
+				roles[key] = true
+				return true
+			}
+			rules = role.Rules
+		}
+
+		roles[key] = createsDeployments(rules)
+		return roles[key]
+	}
+
+	for _, binding := range clusterBindings.Items {
+		if binding.RoleRef.Kind != "ClusterRole" || !bindsServiceAccount(binding.Subjects, c.coreNamespace, account) {
+			continue
+		}
+		if createsDeploymentsIn("", binding.RoleRef) {
+			snapshot.UnboundNamespaces = []string{}
+			return
+		}
+	}
+
+	unbound := []string{}
+	for _, namespace := range namespaces {
+		bindings, err := c.client.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				snapshot.addError("could not list rolebindings in namespace %s: %s", namespace, err)
+			}
+			continue
+		}
+
+		bound := false
+		for _, binding := range bindings.Items {
+			if bindsServiceAccount(binding.Subjects, c.coreNamespace, account) && createsDeploymentsIn(namespace, binding.RoleRef) {
+				bound = true
+				break
+			}
+		}
+		if !bound {
+			unbound = append(unbound, namespace)
+		}
+	}
+	snapshot.UnboundNamespaces = unbound
+}
+
+func bindsServiceAccount(subjects []rbacv1.Subject, namespace, name string) bool {
+	for _, subject := range subjects {
+		if subject.Kind == rbacv1.ServiceAccountKind && subject.Namespace == namespace && subject.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// createsDeployments is true when a rule allows Deployments to be created
+func createsDeployments(rules []rbacv1.PolicyRule) bool {
+	for _, rule := range rules {
+		if (contains(rule.APIGroups, "apps") || contains(rule.APIGroups, "*")) &&
+			(contains(rule.Resources, "deployments") || contains(rule.Resources, "*")) &&
+			(contains(rule.Verbs, "create") || contains(rule.Verbs, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_selectFunctionNamespaces(t *testing.T) {
+	labelled := *newNamespace("dev-fn", nil)
+	labelled.Labels = map[string]string{"openfaas": "1"}
+
+	namespaces := []corev1.Namespace{
+		*newNamespace("openfaas", nil),
+		*newNamespace("openfaas-fn", nil),
+		*newNamespace("staging-fn", map[string]string{"openfaas": "1"}),
+		*newNamespace("disabled-fn", map[string]string{"openfaas": "0"}),
+		labelled,
+	}
+
+	cases := []struct {
+		name           string
+		controller     Controller
+		namespaces     []corev1.Namespace
+		listed         bool
+		wantSelected   []string
+		wantMissing    []string
+		wantUnselected []string
+	}{
+		{
+			name:         "cluster_role uses marked namespaces",
+			controller:   Controller{Mode: "operator", ClusterRole: true},
+			namespaces:   namespaces,
+			listed:       true,
+			wantSelected: []string{"dev-fn", "openfaas-fn", "staging-fn"},
+		},
+		{
+			name:           "without cluster_role only the controller's namespace is used",
+			controller:     Controller{Mode: "faas-netes"},
+			namespaces:     namespaces,
+			listed:         true,
+			wantSelected:   []string{"openfaas-fn"},
+			wantUnselected: []string{"dev-fn", "staging-fn"},
+		},
+		{
+			name:         "function_namespace replaces openfaas-fn",
+			controller:   Controller{Mode: "faas-netes", FunctionNamespace: "staging-fn"},
+			namespaces:   namespaces,
+			listed:       true,
+			wantSelected: []string{"staging-fn"},
+			// dev-fn is still marked, but not used
+			wantUnselected: []string{"dev-fn"},
+		},
+		{
+			name:         "missing function namespace",
+			controller:   Controller{Mode: "operator", ClusterRole: true},
+			namespaces:   namespaces[2:3],
+			listed:       true,
+			wantSelected: []string{"staging-fn"},
+			wantMissing:  []string{"openfaas-fn"},
+		},
+		{
+			name:         "namespaces not listed",
+			controller:   Controller{Mode: "operator"},
+			listed:       false,
+			wantSelected: []string{"openfaas-fn"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, missing, unselected := selectFunctionNamespaces(tc.controller, tc.namespaces, tc.listed)
+			if !reflect.DeepEqual(selected, tc.wantSelected) {
+				t.Errorf("want selected %v, got %v", tc.wantSelected, selected)
+			}
+			if !reflect.DeepEqual(missing, tc.wantMissing) {
+				t.Errorf("want missing %v, got %v", tc.wantMissing, missing)
+			}
+			if !reflect.DeepEqual(unselected, tc.wantUnselected) {
+				t.Errorf("want unselected %v, got %v", tc.wantUnselected, unselected)
+			}
+		})
+	}
+}
+
+func newRoleBinding(namespace, name, kind, role, account string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		RoleRef:    rbacv1.RoleRef{Kind: kind, Name: role},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Name: account, Namespace: "openfaas"},
+		},
+	}
+}
+
+func Test_Collect_Bindings(t *testing.T) {
+	objects := func() []runtime.Object {
+		gateway := newDeployment("openfaas", "gateway", 1, map[string]string{"app": "openfaas"},
+			corev1.Container{Name: "gateway", Image: "ghcr.io/openfaasltd/gateway:0.3.0"},
+			corev1.Container{Name: "operator", Image: "ghcr.io/openfaasltd/faas-netes:0.5.0", Env: env("cluster_role", "true")})
+		gateway.Spec.Template.Spec.ServiceAccountName = "openfaas-controller"
+
+		marked := map[string]string{"openfaas": "1"}
+		return []runtime.Object{
+			newNamespace("openfaas", nil),
+			newNamespace("openfaas-fn", nil),
+			newNamespace("staging-fn", marked),
+			newNamespace("dev-fn", marked),
+			newNamespace("broken-fn", marked),
+			gateway,
+			&rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{Name: "openfaas-controller"},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "list", "create"}},
+				},
+			},
+			&rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Name: "view", Namespace: "dev-fn"},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "list"}},
+				},
+			},
+			newRoleBinding("openfaas-fn", "openfaas-controller", "ClusterRole", "openfaas-controller", "openfaas-controller"),
+			// Bound to another ServiceAccount
+			newRoleBinding("staging-fn", "ci", "ClusterRole", "openfaas-controller", "ci"),
+			// Bound to a Role which can not create Deployments
+			newRoleBinding("dev-fn", "openfaas-controller", "Role", "view", "openfaas-controller"),
+		}
+	}
+
+	client := fake.NewSimpleClientset(objects()...)
+	client.PrependReactor("list", "rolebindings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() != "broken-fn" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewForbidden(rbacv1.Resource("rolebindings"), "", fmt.Errorf("RBAC"))
+	})
+
+	snapshot, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := snapshot.Components.Controller.ServiceAccount; got != "openfaas-controller" {
+		t.Errorf("want the gateway's ServiceAccount, got %q", got)
+	}
+	// broken-fn could not be checked, but the other namespaces still are
+	if want := []string{"dev-fn", "staging-fn"}; !reflect.DeepEqual(snapshot.UnboundNamespaces, want) {
+		t.Errorf("want unbound namespaces %v, got %v", want, snapshot.UnboundNamespaces)
+	}
+	if len(snapshot.Errors) != 1 || !strings.Contains(snapshot.Errors[0], "could not list rolebindings in namespace broken-fn") {
+		t.Errorf("want an error for broken-fn, got: %v", snapshot.Errors)
+	}
+
+	// A ClusterRoleBinding which can create Deployments covers every namespace
+	client = fake.NewSimpleClientset(append(objects(),
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "openfaas-controller"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "openfaas-controller"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "openfaas-controller", Namespace: "openfaas"},
+			},
+		})...)
+	snapshot, err = NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(snapshot.UnboundNamespaces) > 0 {
+		t.Errorf("want no unbound namespaces, got %v", snapshot.UnboundNamespaces)
+	}
+}
+
+func Test_Analyze_Namespaces(t *testing.T) {
+	snapshot := newSnapshot()
+	snapshot.Components.Controller.ServiceAccount = "openfaas-controller"
+	snapshot.MissingNamespaces = []string{"openfaas-fn"}
+	snapshot.UnselectedNamespaces = []string{"dev-fn"}
+	snapshot.UnboundNamespaces = []string{"staging-fn"}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	if !hasFinding(findings, "OF-NS-001", "namespace openfaas-fn does not exist") {
+		t.Errorf("want OF-NS-001 for openfaas-fn, got %v", findings)
+	}
+	if !hasFinding(findings, "OF-NS-002", "namespace dev-fn is marked") {
+		t.Errorf("want OF-NS-002 for dev-fn, got %v", findings)
+	}
+	if !hasFinding(findings, "OF-NS-003", "openfaas-controller.openfaas") {
+		t.Errorf("want OF-NS-003 for staging-fn, got %v", findings)
+	}
+
+	// The namespace a finding is about can ignore the rule
+	snapshot.IgnoredRules = map[string][]string{
+		"staging-fn": {"OF-NS-003"},
+		"dev-fn":     {"OF-NS-003"},
+	}
+
+	for _, f := range Analyze(snapshot, DefaultConfig()) {
+		if !strings.HasPrefix(f.RuleID, "OF-NS-") {
+			continue
+		}
+		if f.Namespace != strings.Fields(f.Message)[1] {
+			t.Errorf("want %s to be for the namespace in its message, got %q: %s", f.RuleID, f.Namespace, f.Message)
+		}
+		if want := f.RuleID == "OF-NS-003"; f.Suppressed != want {
+			t.Errorf("want %s for %s suppressed: %v, got %v", f.RuleID, f.Namespace, want, f.Suppressed)
+		}
+	}
+}
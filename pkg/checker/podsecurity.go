@@ -0,0 +1,218 @@
+package checker
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Pod Security Standards levels, from the most to the least permissive
+// https://kubernetes.io/docs/concepts/security/pod-security-standards/
+const (
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+)
+
+// baselineCapabilities may be added to a container at the baseline level
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE":      true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"KILL":             true,
+	"MKNOD":            true,
+	"NET_BIND_SERVICE": true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYS_CHROOT":       true,
+}
+
+// PodSecurity is how a function's Pod spec is evaluated against the Pod
+// Security Standards
+type PodSecurity struct {
+	// Level is the most restrictive level the function passes
+	Level string `json:"level"`
+
+	// Baseline are the reasons the function does not pass the baseline
+	// level, Restricted are the additional reasons it does not pass the
+	// restricted level
+	Baseline   []string `json:"baseline,omitempty"`
+	Restricted []string `json:"restricted,omitempty"`
+
+	// AutomountServiceAccountToken is true unless the Pod spec disables
+	// it, the ServiceAccount may still disable it
+	AutomountServiceAccountToken bool `json:"automountServiceAccountToken"`
+}
+
+// NamespacePodSecurity is the most restrictive level which every function
+// in a namespace passes, so that it can be enforced with the
+// pod-security.kubernetes.io/enforce label, and the number of functions
+// which pass each level
+type NamespacePodSecurity struct {
+	Level      string `json:"level"`
+	Restricted int    `json:"restricted"`
+	Baseline   int    `json:"baseline"`
+	Privileged int    `json:"privileged"`
+}
+
+// PodSecurityByNamespace returns the level of each function namespace,
+// only functions whose Pod spec was evaluated are counted and a namespace
+// without any is left out
+func (s *ClusterSnapshot) PodSecurityByNamespace() map[string]NamespacePodSecurity {
+	namespaces := map[string]NamespacePodSecurity{}
+	for _, namespace := range s.FunctionNamespaces {
+		var summary NamespacePodSecurity
+		for _, fn := range s.Functions[namespace] {
+			if fn.PodSecurity == nil {
+				continue
+			}
+			switch fn.PodSecurity.Level {
+			case PodSecurityRestricted:
+				summary.Restricted++
+			case PodSecurityBaseline:
+				summary.Baseline++
+			default:
+				summary.Privileged++
+			}
+		}
+		if summary.Restricted+summary.Baseline+summary.Privileged == 0 {
+			continue
+		}
+
+		switch {
+		case summary.Privileged > 0:
+			summary.Level = PodSecurityPrivileged
+		case summary.Baseline > 0:
+			summary.Level = PodSecurityBaseline
+		default:
+			summary.Level = PodSecurityRestricted
+		}
+		namespaces[namespace] = summary
+	}
+	return namespaces
+}
+
+// evaluatePodSecurity checks a Pod spec against the controls of the
+// baseline and restricted levels which apply to functions. The AppArmor,
+// SELinux, /proc mount and sysctl controls are not checked.
+func evaluatePodSecurity(spec corev1.PodSpec) *PodSecurity {
+	var baseline, restricted []string
+
+	if spec.HostNetwork {
+		baseline = append(baseline, "hostNetwork is true")
+	}
+	if spec.HostPID {
+		baseline = append(baseline, "hostPID is true")
+	}
+	if spec.HostIPC {
+		baseline = append(baseline, "hostIPC is true")
+	}
+
+	for _, volume := range spec.Volumes {
+		switch {
+		case volume.HostPath != nil:
+			baseline = append(baseline, fmt.Sprintf("volume %s is a hostPath", volume.Name))
+		case volume.ConfigMap != nil, volume.CSI != nil, volume.DownwardAPI != nil, volume.EmptyDir != nil,
+			volume.Ephemeral != nil, volume.PersistentVolumeClaim != nil, volume.Projected != nil, volume.Secret != nil:
+		default:
+			restricted = append(restricted, fmt.Sprintf("volume %s is not of an allowed type", volume.Name))
+		}
+	}
+
+	pod := spec.SecurityContext
+	if pod == nil {
+		pod = &corev1.PodSecurityContext{}
+	}
+	if pod.SeccompProfile != nil && pod.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		baseline = append(baseline, "seccompProfile is Unconfined")
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		sc := container.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+
+		if sc.Privileged != nil && *sc.Privileged {
+			baseline = append(baseline, fmt.Sprintf("container %s is privileged", container.Name))
+		}
+
+		for _, port := range container.Ports {
+			if port.HostPort != 0 {
+				baseline = append(baseline, fmt.Sprintf("container %s uses hostPort %d", container.Name, port.HostPort))
+			}
+		}
+
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			baseline = append(baseline, fmt.Sprintf("container %s seccompProfile is Unconfined", container.Name))
+		}
+
+		dropAll := false
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !baselineCapabilities[capability] {
+					baseline = append(baseline, fmt.Sprintf("container %s adds capability %s", container.Name, capability))
+				} else if capability != "NET_BIND_SERVICE" {
+					restricted = append(restricted, fmt.Sprintf("container %s adds capability %s", container.Name, capability))
+				}
+			}
+			for _, capability := range sc.Capabilities.Drop {
+				if capability == "ALL" {
+					dropAll = true
+				}
+			}
+		}
+		if !dropAll {
+			restricted = append(restricted, fmt.Sprintf("container %s does not drop ALL capabilities", container.Name))
+		}
+
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			restricted = append(restricted, fmt.Sprintf("container %s does not set allowPrivilegeEscalation to false", container.Name))
+		}
+
+		// The container's settings override those of the Pod
+		runAsNonRoot := pod.RunAsNonRoot
+		if sc.RunAsNonRoot != nil {
+			runAsNonRoot = sc.RunAsNonRoot
+		}
+		if runAsNonRoot == nil || !*runAsNonRoot {
+			restricted = append(restricted, fmt.Sprintf("container %s does not set runAsNonRoot to true", container.Name))
+		}
+
+		runAsUser := pod.RunAsUser
+		if sc.RunAsUser != nil {
+			runAsUser = sc.RunAsUser
+		}
+		if runAsUser != nil && *runAsUser == 0 {
+			restricted = append(restricted, fmt.Sprintf("container %s sets runAsUser to 0", container.Name))
+		}
+
+		seccomp := pod.SeccompProfile
+		if sc.SeccompProfile != nil {
+			seccomp = sc.SeccompProfile
+		}
+		if seccomp == nil {
+			restricted = append(restricted, fmt.Sprintf("container %s has no seccompProfile", container.Name))
+		}
+	}
+
+	security := &PodSecurity{
+		Level:                        PodSecurityRestricted,
+		Baseline:                     baseline,
+		Restricted:                   restricted,
+		AutomountServiceAccountToken: spec.AutomountServiceAccountToken == nil || *spec.AutomountServiceAccountToken,
+	}
+	switch {
+	case len(baseline) > 0:
+		security.Level = PodSecurityPrivileged
+	case len(restricted) > 0:
+		security.Level = PodSecurityBaseline
+	}
+
+	return security
+}
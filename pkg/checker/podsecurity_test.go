@@ -0,0 +1,186 @@
+package checker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func Test_evaluatePodSecurity_Restricted(t *testing.T) {
+	spec := corev1.PodSpec{
+		AutomountServiceAccountToken: boolPtr(false),
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot:   boolPtr(true),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []corev1.Container{{
+			Name: "env",
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: boolPtr(false),
+				Capabilities: &corev1.Capabilities{
+					Add:  []corev1.Capability{"NET_BIND_SERVICE"},
+					Drop: []corev1.Capability{"ALL"},
+				},
+			},
+		}},
+		Volumes: []corev1.Volume{{
+			Name:         "tmp",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}},
+	}
+
+	want := &PodSecurity{Level: PodSecurityRestricted}
+	if got := evaluatePodSecurity(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func Test_evaluatePodSecurity_Baseline(t *testing.T) {
+	spec := corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot: boolPtr(true),
+		},
+		Containers: []corev1.Container{{
+			Name: "env",
+			SecurityContext: &corev1.SecurityContext{
+				RunAsUser: int64Ptr(0),
+				Capabilities: &corev1.Capabilities{
+					Add: []corev1.Capability{"CHOWN"},
+				},
+			},
+		}},
+	}
+
+	got := evaluatePodSecurity(spec)
+
+	want := &PodSecurity{
+		Level: PodSecurityBaseline,
+		Restricted: []string{
+			"container env adds capability CHOWN",
+			"container env does not drop ALL capabilities",
+			"container env does not set allowPrivilegeEscalation to false",
+			"container env sets runAsUser to 0",
+			"container env has no seccompProfile",
+		},
+		AutomountServiceAccountToken: true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func Test_evaluatePodSecurity_Privileged(t *testing.T) {
+	spec := corev1.PodSpec{
+		HostNetwork: true,
+		Containers: []corev1.Container{{
+			Name: "env",
+			SecurityContext: &corev1.SecurityContext{
+				Privileged:     boolPtr(true),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+				Capabilities: &corev1.Capabilities{
+					Add: []corev1.Capability{"SYS_ADMIN"},
+				},
+			},
+			Ports: []corev1.ContainerPort{{ContainerPort: 8080, HostPort: 8080}},
+		}},
+		Volumes: []corev1.Volume{{
+			Name:         "docker",
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"}},
+		}},
+	}
+
+	got := evaluatePodSecurity(spec)
+
+	if got.Level != PodSecurityPrivileged {
+		t.Errorf("want level %s, got %s", PodSecurityPrivileged, got.Level)
+	}
+
+	want := []string{
+		"hostNetwork is true",
+		"volume docker is a hostPath",
+		"container env is privileged",
+		"container env uses hostPort 8080",
+		"container env seccompProfile is Unconfined",
+		"container env adds capability SYS_ADMIN",
+	}
+	if !reflect.DeepEqual(got.Baseline, want) {
+		t.Errorf("want baseline violations %v, got %v", want, got.Baseline)
+	}
+}
+
+func Test_Analyze_PodSecurityNamespaceLevel(t *testing.T) {
+	snapshot := newSnapshot()
+
+	restricted := newFunction("restricted")
+	restricted.PodSecurity = &PodSecurity{Level: PodSecurityRestricted}
+
+	baseline := newFunction("baseline")
+	baseline.PodSecurity = &PodSecurity{Level: PodSecurityBaseline, Restricted: []string{"container baseline has no seccompProfile"}}
+
+	snapshot.Functions["openfaas-fn"] = []Function{restricted, baseline}
+
+	want := map[string]NamespacePodSecurity{
+		"openfaas-fn": {Level: PodSecurityBaseline, Restricted: 1, Baseline: 1},
+	}
+	if got := snapshot.PodSecurityByNamespace(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want namespace levels %+v, got %+v", want, got)
+	}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	if !hasFinding(findings, "OF-PSS-002", "baseline.openfaas-fn does not pass the restricted level: container baseline has no seccompProfile") {
+		t.Errorf("want a restricted finding for baseline, got: %v", findings)
+	}
+	if hasFinding(findings, "OF-PSS-002", "restricted.openfaas-fn") || hasFinding(findings, "OF-PSS-001", "") {
+		t.Errorf("want no other pod security findings, got: %v", findings)
+	}
+}
+
+func Test_Analyze_PodSecurityNotEvaluated(t *testing.T) {
+	snapshot := newSnapshot()
+
+	// Functions read from a stack.yml have no Pod spec to evaluate
+	fn := newFunction("env")
+	fn.PodSecurity = nil
+	snapshot.Functions["openfaas-fn"] = []Function{fn}
+
+	for _, f := range Analyze(snapshot, DefaultConfig()) {
+		if strings.HasPrefix(f.RuleID, "OF-PSS-") {
+			t.Errorf("want no pod security findings, got: %s %s", f.RuleID, f.Message)
+		}
+	}
+	if got := snapshot.PodSecurityByNamespace(); len(got) != 0 {
+		t.Errorf("want no namespace levels, got %+v", got)
+	}
+}
+
+func Test_Analyze_PodSecurityAutomountToken(t *testing.T) {
+	snapshot := newSnapshot()
+
+	mounted := newFunction("mounted")
+	mounted.PodSecurity = &PodSecurity{Level: PodSecurityRestricted, AutomountServiceAccountToken: true}
+
+	disabled := newFunction("disabled")
+	disabled.PodSecurity = &PodSecurity{Level: PodSecurityRestricted}
+
+	snapshot.Functions["openfaas-fn"] = []Function{mounted, disabled}
+
+	findings := Analyze(snapshot, DefaultConfig())
+
+	if !hasFinding(findings, "OF-PSS-003", "mounted.openfaas-fn does not set automountServiceAccountToken to false") {
+		t.Errorf("want OF-PSS-003 for mounted, got: %v", findings)
+	}
+	if hasFinding(findings, "OF-PSS-003", "disabled.openfaas-fn") {
+		t.Errorf("want no OF-PSS-003 for disabled, got: %v", findings)
+	}
+}
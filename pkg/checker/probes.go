@@ -0,0 +1,117 @@
+package checker
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Annotations which set the paths of a function's HTTP probes
+const (
+	HealthPathAnnotation = "com.openfaas.health.http.path"
+	ReadyPathAnnotation  = "com.openfaas.ready.http.path"
+)
+
+// watchdogHealthPath is served by the watchdog, rather than the function
+const watchdogHealthPath = "/_/health"
+
+// Probes are the liveness and readiness probes of a function's container,
+// and the paths requested for them through annotations
+type Probes struct {
+	// Liveness and Readiness are nil when the probe is not set
+	Liveness  *Probe `json:"liveness,omitempty"`
+	Readiness *Probe `json:"readiness,omitempty"`
+
+	// HealthPath and ReadyPath are the values of HealthPathAnnotation and
+	// ReadyPathAnnotation, which are empty when not set
+	HealthPath string `json:"healthPath,omitempty"`
+	ReadyPath  string `json:"readyPath,omitempty"`
+}
+
+// Probe is a container probe, with Kubernetes' defaults applied to any
+// values which are not set
+type Probe struct {
+	// Type is one of http, exec, tcp or grpc
+	Type string `json:"type"`
+
+	// Path is only set for an http probe
+	Path string `json:"path,omitempty"`
+
+	InitialDelaySeconds int `json:"initialDelaySeconds"`
+	PeriodSeconds       int `json:"periodSeconds"`
+	TimeoutSeconds      int `json:"timeoutSeconds"`
+	FailureThreshold    int `json:"failureThreshold"`
+}
+
+// readyAfter is the longest a Pod can wait after it starts for the probe
+// to first be run
+func (p *Probe) readyAfter() time.Duration {
+	return time.Duration(p.InitialDelaySeconds+p.PeriodSeconds) * time.Second
+}
+
+// failsAfter is how long the probe has to fail for before it takes effect
+func (p *Probe) failsAfter() time.Duration {
+	return time.Duration(p.PeriodSeconds*p.FailureThreshold) * time.Second
+}
+
+// readProbes reads the probes of a function's container and the paths set
+// through the Deployment's annotations, which faas-netes and the operator
+// copy to the Pod template
+func readProbes(container corev1.Container, annotations ...map[string]string) *Probes {
+	probes := &Probes{
+		Liveness:  readProbe(container.LivenessProbe),
+		Readiness: readProbe(container.ReadinessProbe),
+	}
+
+	for _, a := range annotations {
+		if path, ok := a[HealthPathAnnotation]; ok && len(probes.HealthPath) == 0 {
+			probes.HealthPath = path
+		}
+		if path, ok := a[ReadyPathAnnotation]; ok && len(probes.ReadyPath) == 0 {
+			probes.ReadyPath = path
+		}
+	}
+
+	return probes
+}
+
+func readProbe(probe *corev1.Probe) *Probe {
+	if probe == nil {
+		return nil
+	}
+
+	p := &Probe{
+		InitialDelaySeconds: int(probe.InitialDelaySeconds),
+		PeriodSeconds:       int(probe.PeriodSeconds),
+		TimeoutSeconds:      int(probe.TimeoutSeconds),
+		FailureThreshold:    int(probe.FailureThreshold),
+	}
+
+	// https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/#configure-probes
+	if p.PeriodSeconds == 0 {
+		p.PeriodSeconds = 10
+	}
+	if p.TimeoutSeconds == 0 {
+		p.TimeoutSeconds = 1
+	}
+	if p.FailureThreshold == 0 {
+		p.FailureThreshold = 3
+	}
+
+	switch {
+	case probe.HTTPGet != nil:
+		p.Type = "http"
+		p.Path = probe.HTTPGet.Path
+		if len(p.Path) == 0 {
+			p.Path = "/"
+		}
+	case probe.Exec != nil:
+		p.Type = "exec"
+	case probe.TCPSocket != nil:
+		p.Type = "tcp"
+	case probe.GRPC != nil:
+		p.Type = "grpc"
+	}
+
+	return p
+}
@@ -0,0 +1,48 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_readProbes(t *testing.T) {
+	container := corev1.Container{
+		Name: "env",
+		LivenessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/_/health"},
+			},
+			InitialDelaySeconds: 2,
+			PeriodSeconds:       2,
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{Command: []string{"cat", "/tmp/.lock"}},
+			},
+		},
+	}
+
+	probes := readProbes(container,
+		map[string]string{HealthPathAnnotation: "/healthz"},
+		map[string]string{HealthPathAnnotation: "/ignored", ReadyPathAnnotation: "/ready"})
+
+	want := &Probes{
+		Liveness:   &Probe{Type: "http", Path: "/_/health", InitialDelaySeconds: 2, PeriodSeconds: 2, TimeoutSeconds: 1, FailureThreshold: 3},
+		Readiness:  &Probe{Type: "exec", PeriodSeconds: 10, TimeoutSeconds: 1, FailureThreshold: 3},
+		HealthPath: "/healthz",
+		ReadyPath:  "/ready",
+	}
+	if !reflect.DeepEqual(probes, want) {
+		t.Errorf("want %+v, got %+v", want, probes)
+	}
+}
+
+func Test_readProbes_NotSet(t *testing.T) {
+	probes := readProbes(corev1.Container{Name: "env"}, nil)
+
+	if !reflect.DeepEqual(probes, &Probes{}) {
+		t.Errorf("want no probes, got %+v", probes)
+	}
+}
@@ -0,0 +1,430 @@
+package checker
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// keptNamespaces are the default and system namespaces, which say nothing
+// about the installation and are not redacted
+var keptNamespaces = map[string]bool{
+	"openfaas":     true,
+	"openfaas-fn":  true,
+	"default":      true,
+	"kube-system":  true,
+	"istio-system": true,
+}
+
+// publicRegistries are the hosts of public registries, which are kept so
+// that OpenFaaS images can still be recognised
+var publicRegistries = map[string]bool{
+	"docker.io":       true,
+	"index.docker.io": true,
+	"ghcr.io":         true,
+	"quay.io":         true,
+	"gcr.io":          true,
+	"registry.k8s.io": true,
+	"public.ecr.aws":  true,
+}
+
+// Redactor replaces the names of functions, namespaces, secrets,
+// ServiceAccounts and ConfigMaps, and the hosts of private registries,
+// with hashes so that a report can be
+// shared. The same name is always given the same hash by a Redactor, but
+// each Redactor has its own key, so hashes can not be compared between
+// reports or found by hashing likely names. Image tags and settings are
+// not changed.
+type Redactor struct {
+	key []byte
+
+	// hashes maps each kind of name and original value to its hash, so
+	// that a namespace and a function with the same name are not confused
+	hashes map[string]string
+
+	// replaced maps each original value to its hash, for replacing names
+	// within messages
+	replaced map[string]string
+}
+
+// NewRedactor returns a Redactor with a random key
+func NewRedactor() (*Redactor, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return newRedactorWithKey(key), nil
+}
+
+func newRedactorWithKey(key []byte) *Redactor {
+	return &Redactor{
+		key:      key,
+		hashes:   map[string]string{},
+		replaced: map[string]string{},
+	}
+}
+
+func (r *Redactor) hash(prefix, value string) string {
+	if len(value) == 0 {
+		return value
+	}
+	key := prefix + "\x00" + value
+	if h, ok := r.hashes[key]; ok {
+		return h
+	}
+
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	h := prefix + "-" + hex.EncodeToString(mac.Sum(nil))[:8]
+
+	r.hashes[key] = h
+	if _, ok := r.replaced[value]; !ok {
+		r.replaced[value] = h
+	}
+	return h
+}
+
+func (r *Redactor) namespace(namespace string) string {
+	if keptNamespaces[namespace] {
+		return namespace
+	}
+	return r.hash("ns", namespace)
+}
+
+func (r *Redactor) namespaces(namespaces []string) []string {
+	for i := range namespaces {
+		namespaces[i] = r.namespace(namespaces[i])
+	}
+	return namespaces
+}
+
+func (r *Redactor) function(name string) string {
+	return r.hash("fn", name)
+}
+
+// pod replaces the name of a function's Deployment within the name of
+// one of its Pods, the generated suffix is kept
+func (r *Redactor) pod(deployment, pod string) string {
+	if !strings.HasPrefix(pod, deployment+"-") {
+		return r.hash("pod", pod)
+	}
+
+	redacted := r.function(deployment) + strings.TrimPrefix(pod, deployment)
+	r.replaced[pod] = redacted
+	return redacted
+}
+
+// serviceAccount replaces the name of a ServiceAccount other than the
+// namespace's default one
+func (r *Redactor) serviceAccount(name string) string {
+	if name == "default" {
+		return name
+	}
+	return r.hash("sa", name)
+}
+
+// timeout replaces the names of the ConfigMaps and Secrets which timeouts
+// were read from, such as configmap/name:key, the key is kept
+func (r *Redactor) timeout(t *Timeout) {
+	if t == nil {
+		return
+	}
+	for env, source := range t.Sources {
+		kind, ref, found := strings.Cut(source, "/")
+		if !found || (kind != "configmap" && kind != "secret") {
+			continue
+		}
+		name, key, found := strings.Cut(ref, ":")
+		source = kind + "/" + r.hash(kind, name)
+		if found {
+			source += ":" + key
+		}
+		t.Sources[env] = source
+	}
+}
+
+// event replaces the name of the object an event for a function is
+// about, the Deployment has the function's name and its ReplicaSets and
+// Pods start with it
+func (r *Redactor) event(deployment string, event EventSummary) string {
+	if event.Kind == "Deployment" {
+		return r.function(event.Name)
+	}
+	return r.pod(deployment, event.Name)
+}
+
+// image replaces the registry host of an image when it is not a public
+// registry, the repository and tag are kept
+func (r *Redactor) image(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) < 2 {
+		return image
+	}
+
+	// As with docker, the first part is only a host when it looks like one
+	host := parts[0]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return image
+	}
+	if publicRegistries[host] {
+		return image
+	}
+
+	return r.hash("registry", host) + "/" + parts[1]
+}
+
+// Redact replaces the names in the snapshot, and in the findings which
+// were produced from it. The findings should be produced before the
+// snapshot is redacted, so that rules which check names still see them.
+func (r *Redactor) Redact(s *ClusterSnapshot, findings []Finding) {
+	c := &s.Components
+	c.Gateway.Image = r.image(c.Gateway.Image)
+	c.Controller.Image = r.image(c.Controller.Image)
+	c.Controller.ServiceAccount = r.serviceAccount(c.Controller.ServiceAccount)
+	r.timeout(c.Gateway.Timeout)
+	r.timeout(c.Controller.Timeout)
+	if c.QueueWorker != nil {
+		c.QueueWorker.Image = r.image(c.QueueWorker.Image)
+		r.timeout(c.QueueWorker.Timeout)
+	}
+	if c.Autoscaler != nil {
+		c.Autoscaler.Image = r.image(c.Autoscaler.Image)
+	}
+	if c.Dashboard != nil {
+		c.Dashboard.Image = r.image(c.Dashboard.Image)
+	}
+
+	s.CoreNamespace = r.namespace(s.CoreNamespace)
+
+	namespaces := make([]string, 0, len(s.FunctionNamespaces))
+	for _, namespace := range s.FunctionNamespaces {
+		namespaces = append(namespaces, r.namespace(namespace))
+	}
+	s.FunctionNamespaces = namespaces
+	c.Controller.FunctionNamespace = r.namespace(c.Controller.FunctionNamespace)
+	s.MissingNamespaces = r.namespaces(s.MissingNamespaces)
+	s.UnselectedNamespaces = r.namespaces(s.UnselectedNamespaces)
+	s.UnboundNamespaces = r.namespaces(s.UnboundNamespaces)
+
+	if s.SharedNamespaces != nil {
+		shared := make(map[string]string, len(s.SharedNamespaces))
+		for namespace, owner := range s.SharedNamespaces {
+			shared[r.namespace(namespace)] = r.namespace(owner)
+		}
+		s.SharedNamespaces = shared
+	}
+
+	functions := make(map[string][]Function, len(s.Functions))
+	for namespace, fns := range s.Functions {
+		for i := range fns {
+			name := fns[i].Name
+			fns[i].Name = r.function(name)
+			r.timeout(fns[i].Timeout)
+			if h := fns[i].Health; h != nil {
+				for j := range h.Pods {
+					h.Pods[j].Name = r.pod(name, h.Pods[j].Name)
+					// The function's container is named after it
+					for k := range h.Pods[j].Containers {
+						if h.Pods[j].Containers[k].Name == name {
+							h.Pods[j].Containers[k].Name = fns[i].Name
+						}
+					}
+				}
+				for j := range h.Events {
+					h.Events[j].Name = r.event(name, h.Events[j])
+				}
+			}
+			for j := range fns[i].ExternalScalers {
+				fns[i].ExternalScalers[j].Name = r.hash("scaler", fns[i].ExternalScalers[j].Name)
+			}
+		}
+		functions[r.namespace(namespace)] = fns
+	}
+	s.Functions = functions
+
+	if s.OtherWorkloads != nil {
+		workloads := make(map[string][]Workload, len(s.OtherWorkloads))
+		for namespace, items := range s.OtherWorkloads {
+			for i := range items {
+				items[i].Name = r.hash("workload", items[i].Name)
+				for j := range items[i].Images {
+					items[i].Images[j] = r.image(items[i].Images[j])
+				}
+			}
+			workloads[r.namespace(namespace)] = items
+		}
+		s.OtherWorkloads = workloads
+	}
+
+	if s.UnreadFunctions != nil {
+		unread := make(map[string][]string, len(s.UnreadFunctions))
+		for namespace, names := range s.UnreadFunctions {
+			for i := range names {
+				names[i] = r.function(names[i])
+			}
+			unread[r.namespace(namespace)] = names
+		}
+		s.UnreadFunctions = unread
+	}
+
+	if s.FunctionCRs != nil {
+		crs := make(map[string][]FunctionCR, len(s.FunctionCRs))
+		for namespace, items := range s.FunctionCRs {
+			for i := range items {
+				cr := &items[i]
+				cr.Name = r.function(cr.Name)
+				cr.FunctionName = r.function(cr.FunctionName)
+				cr.Image = r.image(cr.Image)
+				for j := range cr.Secrets {
+					cr.Secrets[j] = r.hash("secret", cr.Secrets[j])
+				}
+			}
+			crs[r.namespace(namespace)] = items
+		}
+		s.FunctionCRs = crs
+	}
+
+	if s.IgnoredRules != nil {
+		ignored := make(map[string][]string, len(s.IgnoredRules))
+		for namespace, rules := range s.IgnoredRules {
+			ignored[r.namespace(namespace)] = rules
+		}
+		s.IgnoredRules = ignored
+	}
+
+	// Messages are replaced once every name is known, including the
+	// ConfigMaps which are only named within them
+	messages := []*string{}
+	for i := range s.Errors {
+		messages = append(messages, &s.Errors[i])
+	}
+	for i := range s.ParseErrors {
+		messages = append(messages, &s.ParseErrors[i])
+	}
+	for _, h := range s.CoreHealth {
+		messages = append(messages, healthMessages(h)...)
+	}
+	for _, fns := range s.Functions {
+		for i := range fns {
+			messages = append(messages, functionMessages(&fns[i])...)
+		}
+	}
+	for i := range findings {
+		messages = append(messages, &findings[i].Message)
+	}
+
+	for _, message := range messages {
+		r.configMaps(*message)
+	}
+	for _, message := range messages {
+		*message = r.replace(*message)
+	}
+
+	for i := range findings {
+		f := &findings[i]
+		if len(f.Namespace) > 0 {
+			f.Namespace = r.namespace(f.Namespace)
+		}
+		if len(f.Function) > 0 {
+			f.Function = r.function(f.Function)
+		}
+	}
+}
+
+// healthMessages returns the messages within a workload's health, which
+// may name its Pods and ConfigMaps
+func healthMessages(h *WorkloadHealth) []*string {
+	if h == nil {
+		return nil
+	}
+	var messages []*string
+	for i := range h.Pods {
+		messages = append(messages, &h.Pods[i].Unschedulable)
+	}
+	for i := range h.Events {
+		messages = append(messages, &h.Events[i].Message)
+	}
+	return messages
+}
+
+// functionMessages returns the messages about a function, which name its
+// container
+func functionMessages(fn *Function) []*string {
+	messages := healthMessages(fn.Health)
+	for i := range fn.ParseErrors {
+		messages = append(messages, &fn.ParseErrors[i])
+	}
+	if ps := fn.PodSecurity; ps != nil {
+		for i := range ps.Baseline {
+			messages = append(messages, &ps.Baseline[i])
+		}
+		for i := range ps.Restricted {
+			messages = append(messages, &ps.Restricted[i])
+		}
+	}
+	return messages
+}
+
+// configMapName matches a ConfigMap named in a message, such as
+// "configmap openfaas-fn/timeouts" or `configmaps "timeouts" not found`
+var configMapName = regexp.MustCompile(`configmaps? "?(?:[a-z0-9][a-z0-9.-]*/)?([a-z0-9][a-z0-9.-]*)`)
+
+// configMaps hashes the names of the ConfigMaps within a message, so that
+// replace finds them
+func (r *Redactor) configMaps(message string) {
+	for _, match := range configMapName.FindAllStringSubmatch(message, -1) {
+		r.hash("configmap", strings.TrimRight(match[1], "."))
+	}
+}
+
+// replace replaces every whole name which has been redacted within a
+// message, the longest names first so that a name which is part of
+// another one is not replaced within it
+func (r *Redactor) replace(message string) string {
+	names := make([]string, 0, len(r.replaced))
+	for name := range r.replaced {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		message = replaceName(message, name, r.replaced[name])
+	}
+	return message
+}
+
+// replaceName replaces old in s where it is not part of a longer name,
+// for example "env" in "env.openfaas-fn" but not in "environment"
+func replaceName(s, old, new string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, old)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		end := i + len(old)
+		if (i == 0 || !isNameByte(s[i-1])) && (end == len(s) || !isNameByte(s[end])) {
+			b.WriteString(s[:i])
+			b.WriteString(new)
+		} else {
+			b.WriteString(s[:end])
+		}
+		s = s[end:]
+	}
+}
+
+func isNameByte(c byte) bool {
+	return c == '-' || c == '_' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
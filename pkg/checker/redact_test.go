@@ -0,0 +1,263 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Redactor_image(t *testing.T) {
+	r := newRedactorWithKey([]byte("test"))
+
+	for _, image := range []string{
+		"ghcr.io/openfaas/gateway:0.25.2",
+		"openfaas/gateway:0.25.2",
+		"nats-streaming:0.22.0",
+	} {
+		if got := r.image(image); got != image {
+			t.Errorf("want %s to be kept, got %s", image, got)
+		}
+	}
+
+	got := r.image("registry.acme.com:5000/openfaas/gateway:0.25.2")
+	if strings.Contains(got, "acme") {
+		t.Errorf("want the registry host to be redacted, got %s", got)
+	}
+	if !strings.HasPrefix(got, "registry-") || !strings.HasSuffix(got, "/openfaas/gateway:0.25.2") {
+		t.Errorf("want the repository and tag to be kept, got %s", got)
+	}
+}
+
+func Test_Redactor_Redact(t *testing.T) {
+	core := map[string]string{"app": "openfaas"}
+	gateway := newDeployment("openfaas", "gateway", 1, core,
+		corev1.Container{
+			Name:  "gateway",
+			Image: "ghcr.io/openfaas/gateway:0.25.2",
+			Env: []corev1.EnvVar{
+				configMapKeyRef("upstream_timeout", "acme-gateway", "upstream"),
+			},
+			EnvFrom: []corev1.EnvFromSource{{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "acme-missing"},
+				},
+			}},
+		},
+		corev1.Container{
+			Name:  "operator",
+			Image: "ghcr.io/openfaasltd/faas-netes:0.5.0",
+			Env:   env("cluster_role", "true"),
+		})
+	gateway.Spec.Template.Spec.ServiceAccountName = "acme-controller"
+
+	labels := map[string]string{"faas_function": "invoice"}
+	invoice := newDeployment("acme-billing", "invoice", 1, labels,
+		corev1.Container{
+			Name:  "invoice",
+			Image: "registry.acme.corp/invoice:1.0",
+			Env: []corev1.EnvVar{
+				configMapKeyRef("write_timeout", "acme-timeouts", "write_timeout"),
+				configMapKeyRef("read_timeout", "acme-timeouts", "read"),
+			},
+			SecurityContext: &corev1.SecurityContext{Privileged: boolPtr(true)},
+		})
+	invoice.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+
+	pod := newPod("acme-billing", "invoice-6c8b-abcde", labels, corev1.PodRunning, corev1.ContainerStatus{
+		Name:         "invoice",
+		RestartCount: 12,
+		LastTerminationState: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"},
+		},
+	})
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "invoice.1", Namespace: "acme-billing"},
+		Type:           corev1.EventTypeWarning,
+		InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Name: "invoice", Namespace: "acme-billing"},
+		Reason:         "FailedCreate",
+		Message:        `Error: configmap "acme-timeouts" is being deleted`,
+		Count:          2,
+	}
+
+	client := fake.NewSimpleClientset(
+		newNamespace("openfaas", nil),
+		newNamespace("openfaas-fn", nil),
+		newNamespace("acme-billing", map[string]string{"openfaas": "1"}),
+		gateway, invoice, pod, event,
+		newDeployment("acme-billing", "acme-ledger", 1, nil,
+			corev1.Container{Name: "postgres", Image: "registry.acme.corp/postgres:15"}),
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "acme-gateway", Namespace: "openfaas"},
+			Data:       map[string]string{"other": "1m"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "acme-timeouts", Namespace: "acme-billing"},
+			Data:       map[string]string{"write_timeout": "30s"},
+		},
+	)
+
+	s, err := NewCollector(client, "openfaas").Collect(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	findings := Analyze(s, DefaultConfig())
+
+	names := []string{"acme", "invoice"}
+	marshal := func() string {
+		out, err := json.Marshal(struct {
+			Snapshot *ClusterSnapshot `json:"snapshot"`
+			Findings []Finding        `json:"findings"`
+		}{s, findings})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return string(out)
+	}
+
+	// Each name is somewhere in the report before it is redacted
+	before := marshal()
+	for _, want := range []string{
+		"acme-billing", "invoice-6c8b-abcde", "acme-ledger", "registry.acme.corp",
+		"configmap/acme-timeouts:write_timeout", "openfaas/acme-gateway has no key upstream",
+		"acme-billing/acme-timeouts has no key read", "openfaas/acme-missing", "acme-controller",
+		"container invoice", `"name":"invoice","restarts":12`, `configmap \"acme-timeouts\"`,
+	} {
+		if !strings.Contains(before, want) {
+			t.Errorf("want %q in the report before it is redacted", want)
+		}
+	}
+
+	r := newRedactorWithKey([]byte("test"))
+	r.Redact(s, findings)
+
+	after := marshal()
+	for _, name := range names {
+		if i := strings.Index(after, name); i >= 0 {
+			start, end := i-60, i+60
+			if start < 0 {
+				start = 0
+			}
+			if end > len(after) {
+				end = len(after)
+			}
+			t.Errorf("want %q to be redacted, found in: ...%s...", name, after[start:end])
+		}
+	}
+
+	// The same names have the same hashes throughout the report
+	var namespace string
+	for _, ns := range s.FunctionNamespaces {
+		if strings.HasPrefix(ns, "ns-") {
+			namespace = ns
+		}
+	}
+	fns := s.Functions[namespace]
+	if len(fns) != 1 {
+		t.Fatalf("want the function under its redacted namespace, got %v", s.Functions)
+	}
+	name := fns[0].Name
+	if !strings.HasPrefix(name, "fn-") {
+		t.Errorf("want the function name to be hashed, got %s", name)
+	}
+	if got := fns[0].Health.Pods[0].Name; got != name+"-6c8b-abcde" {
+		t.Errorf("want the Pod's generated suffix to be kept, got %s", got)
+	}
+	if got := fns[0].Health.Events[0].Name; got != name {
+		t.Errorf("want the Deployment's event to use the function's hash, got %s", got)
+	}
+	for _, f := range findings {
+		if len(f.Function) > 0 && (f.Function != name || f.Namespace != namespace) {
+			t.Errorf("want findings to use the same hashes, got %s.%s", f.Function, f.Namespace)
+		}
+	}
+	if !hasFinding(findings, "OF-POD-009", name+"."+namespace+" pod "+name+"-6c8b-abcde") {
+		t.Errorf("want messages to use the same hashes, got: %v", findings)
+	}
+}
+
+func configMapKeyRef(name, configMap, key string) corev1.EnvVar {
+	return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{
+		ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: configMap},
+			Key:                  key,
+		},
+	}}
+}
+
+func Test_Redactor_SameNameInEachKind(t *testing.T) {
+	r := newRedactorWithKey([]byte("test"))
+
+	fn := r.function("billing")
+	namespace := r.namespace("billing")
+	if !strings.HasPrefix(fn, "fn-") {
+		t.Errorf("want the function to be hashed as a function, got %s", fn)
+	}
+	if !strings.HasPrefix(namespace, "ns-") {
+		t.Errorf("want the namespace to be hashed as a namespace, got %s", namespace)
+	}
+	if got := r.function("billing"); got != fn {
+		t.Errorf("want the same hash for the function, got %s and %s", fn, got)
+	}
+}
+
+func Test_Redactor_Redact_SourcesAndServiceAccount(t *testing.T) {
+	gatewayTimeout := NewTimeout()
+	gatewayTimeout.Sources["upstream_timeout"] = "configmap/acme-gateway:upstream"
+	fnTimeout := NewTimeout()
+	fnTimeout.Sources["write_timeout"] = "configmap/acme-timeouts:write_timeout"
+	fnTimeout.Sources["read_timeout"] = "configmap/acme-timeouts"
+	fnTimeout.Sources["exec_timeout"] = "env"
+	fnTimeout.Sources["upstream_timeout"] = "secret/acme-secrets:upstream"
+
+	s := &ClusterSnapshot{
+		CoreNamespace: "openfaas",
+		Components: Components{
+			Gateway:    Gateway{Timeout: gatewayTimeout},
+			Controller: Controller{Timeout: NewTimeout(), ServiceAccount: "acme-controller"},
+		},
+		FunctionNamespaces: []string{"openfaas-fn"},
+		Functions: map[string][]Function{
+			"openfaas-fn": {{Name: "env", Timeout: fnTimeout}},
+		},
+	}
+	findings := []Finding{
+		{Message: "the controller's ServiceAccount acme-controller.openfaas can not create Deployments"},
+	}
+
+	r := newRedactorWithKey([]byte("test"))
+	r.Redact(s, findings)
+
+	account := s.Components.Controller.ServiceAccount
+	if account == "acme-controller" || !strings.HasPrefix(account, "sa-") {
+		t.Errorf("want the ServiceAccount to be redacted, got %s", account)
+	}
+	if strings.Contains(findings[0].Message, "acme") {
+		t.Errorf("want the ServiceAccount to be redacted in messages, got %s", findings[0].Message)
+	}
+
+	for env, source := range gatewayTimeout.Sources {
+		if strings.Contains(source, "acme") {
+			t.Errorf("want the gateway's %s source to be redacted, got %s", env, source)
+		}
+	}
+
+	sources := s.Functions["openfaas-fn"][0].Timeout.Sources
+	write := sources["write_timeout"]
+	if strings.Contains(write, "acme") || !strings.HasPrefix(write, "configmap/configmap-") || !strings.HasSuffix(write, ":write_timeout") {
+		t.Errorf("want the ConfigMap's name to be redacted and its key kept, got %s", write)
+	}
+	if want := strings.TrimSuffix(write, ":write_timeout"); sources["read_timeout"] != want {
+		t.Errorf("want read_timeout source %s, got %s", want, sources["read_timeout"])
+	}
+	if secret := sources["upstream_timeout"]; strings.Contains(secret, "acme") || !strings.HasPrefix(secret, "secret/secret-") || !strings.HasSuffix(secret, ":upstream") {
+		t.Errorf("want the Secret's name to be redacted and its key kept, got %s", secret)
+	}
+	if sources["exec_timeout"] != "env" {
+		t.Errorf("want a literal source to be kept, got %s", sources["exec_timeout"])
+	}
+}
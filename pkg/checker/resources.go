@@ -0,0 +1,58 @@
+package checker
+
+import (
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// coreContainers are the containers of the core components whose
+// requests and limits are checked
+var coreContainers = []string{"gateway", "faas-netes", "operator", "queue-worker", "autoscaler", "nats"}
+
+// ContainerResources are the requests and limits of a core component's
+// container
+type ContainerResources struct {
+	Requests *FunctionResources `json:"requests"`
+	Limits   *FunctionResources `json:"limits"`
+}
+
+// readResources reads the requests and limits of a container, where "0"
+// is used when a value is not set
+func readResources(container corev1.Container) (*FunctionResources, *FunctionResources) {
+	requests := &FunctionResources{
+		Memory: container.Resources.Requests.Memory().String(),
+		CPU:    container.Resources.Requests.Cpu().String(),
+	}
+	limits := &FunctionResources{
+		Memory: container.Resources.Limits.Memory().String(),
+		CPU:    container.Resources.Limits.Cpu().String(),
+	}
+	return requests, limits
+}
+
+// readCoreResources returns the requests and limits of each container in
+// coreContainers by name
+func readCoreResources(deps []v1.Deployment) map[string]*ContainerResources {
+	resources := map[string]*ContainerResources{}
+	for _, dep := range deps {
+		for _, container := range dep.Spec.Template.Spec.Containers {
+			if !contains(coreContainers, container.Name) {
+				continue
+			}
+			requests, limits := readResources(container)
+			resources[container.Name] = &ContainerResources{Requests: requests, Limits: limits}
+		}
+	}
+	return resources
+}
+
+// quantity parses a value of FunctionResources, false is returned when it
+// is not set or can not be parsed
+func quantity(value string) (resource.Quantity, bool) {
+	if len(value) == 0 || value == "0" {
+		return resource.Quantity{}, false
+	}
+	q, err := resource.ParseQuantity(value)
+	return q, err == nil
+}
@@ -0,0 +1,130 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Severity is how serious a finding is
+type Severity string
+
+const (
+	// SeverityInfo is a recommendation or notice, such as a deprecation
+	SeverityInfo Severity = "info"
+
+	// SeverityWarning is a setting which is likely to cause problems
+	SeverityWarning Severity = "warning"
+
+	// SeverityError is a setting which is known to break OpenFaaS or
+	// functions in production
+	SeverityError Severity = "error"
+)
+
+// ParseSeverity returns the Severity named by s
+func ParseSeverity(s string) (Severity, error) {
+	switch Severity(s) {
+	case SeverityInfo, SeverityWarning, SeverityError:
+		return Severity(s), nil
+	}
+	return "", fmt.Errorf("unknown severity: %q, use info, warning or error", s)
+}
+
+// AtLeast returns true when s is as serious as, or more serious than min
+func (s Severity) AtLeast(min Severity) bool {
+	return s.rank() >= min.rank()
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityError:
+		return 3
+	}
+	return 0
+}
+
+// Category groups rules by the area of configuration they check
+type Category string
+
+const (
+	CategoryAvailability  Category = "availability"
+	CategoryCollection    Category = "collection"
+	CategoryConfiguration Category = "configuration"
+	CategoryDeprecation   Category = "deprecation"
+	CategoryPerformance   Category = "performance"
+	CategoryResources     Category = "resources"
+	CategoryScaling       Category = "scaling"
+	CategorySecurity      Category = "security"
+	CategoryTimeouts      Category = "timeouts"
+)
+
+// Rule is a check with a stable ID. Exactly one of the Check functions
+// is set, and each message it returns becomes a Finding.
+type Rule struct {
+	ID       string   `json:"id"`
+	Severity Severity `json:"severity"`
+	Category Category `json:"category"`
+	Summary  string   `json:"summary"`
+	Docs     string   `json:"docs,omitempty"`
+
+	// CheckCluster is run once against the snapshot
+	CheckCluster func(s *ClusterSnapshot, t Thresholds) []string `json:"-"`
+
+	// CheckClusterNamespaces is run once against the snapshot, for
+	// namespaces which may not be function namespaces, such as one which
+	// does not exist. It returns a message for each namespace it concerns.
+	CheckClusterNamespaces func(s *ClusterSnapshot, t Thresholds) map[string]string `json:"-"`
+
+	// CheckNamespace is run once for each function namespace
+	CheckNamespace func(s *ClusterSnapshot, t Thresholds, namespace string, functions []Function) []string `json:"-"`
+
+	// CheckFunction is run for each function
+	CheckFunction func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string `json:"-"`
+}
+
+var registry []Rule
+
+// Register adds rules to those run by Analyze, rules are run in the
+// order they were registered. It panics if an ID is already in use.
+func Register(rules ...Rule) {
+	for _, rule := range rules {
+		if _, ok := LookupRule(rule.ID); ok {
+			panic(fmt.Sprintf("rule %s is already registered", rule.ID))
+		}
+		registry = append(registry, rule)
+	}
+}
+
+// Rules returns the registered rules sorted by ID
+func Rules() []Rule {
+	rules := make([]Rule, len(registry))
+	copy(rules, registry)
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+	return rules
+}
+
+// LookupRule returns the rule registered with id
+func LookupRule(id string) (Rule, bool) {
+	for _, rule := range registry {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (r Rule) finding(message string) Finding {
+	return Finding{
+		RuleID:   r.ID,
+		Severity: r.Severity,
+		Category: r.Category,
+		Message:  message,
+		Docs:     r.Docs,
+	}
+}
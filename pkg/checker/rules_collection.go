@@ -0,0 +1,92 @@
+package checker
+
+import (
+	"fmt"
+	"time"
+)
+
+func init() {
+	Register(collectionRules...)
+}
+
+// collectionRules report the problems found while collecting and parsing
+// the snapshot, so that a partial report is produced instead of none.
+var collectionRules = []Rule{
+	{
+		ID:       "OF-CHK-001",
+		Severity: SeverityWarning,
+		Category: CategoryCollection,
+		Summary:  "Part of the configuration could not be collected",
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.Errors
+		},
+	},
+	{
+		ID:       "OF-CHK-002",
+		Severity: SeverityError,
+		Category: CategoryConfiguration,
+		Summary:  "Core component setting is missing or could not be parsed",
+		Docs:     docsTimeouts,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			messages := append([]string{}, s.ParseErrors...)
+
+			gateway := s.Components.Gateway
+			if len(gateway.Image) > 0 {
+				if gateway.Timeout == nil || (len(gateway.Timeout.Additional["upstream_timeout"]) == 0 && !gateway.Timeout.IsUnresolved("upstream_timeout")) {
+					messages = append(messages, "gateway upstream_timeout is not set")
+				}
+			}
+			messages = append(messages, on(gateway.Timeout.parseErrors(), "gateway")...)
+
+			controller := s.Components.Controller
+			messages = append(messages, on(controller.Timeout.parseErrors(), controller.Mode)...)
+
+			if queueWorker := s.Components.QueueWorker; queueWorker != nil {
+				if len(queueWorker.AckWait) == 0 {
+					messages = append(messages, "queue-worker ack_wait is not set")
+				} else if _, err := time.ParseDuration(queueWorker.AckWait); err != nil {
+					messages = append(messages, fmt.Sprintf("could not parse ack_wait '%s' on queue-worker", queueWorker.AckWait))
+				}
+
+				if queueWorker.JetStream && (queueWorker.Timeout == nil || (len(queueWorker.Timeout.Additional["upstream_timeout"]) == 0 && !queueWorker.Timeout.IsUnresolved("upstream_timeout"))) {
+					messages = append(messages, "queue-worker upstream_timeout is not set")
+				}
+				messages = append(messages, on(queueWorker.Timeout.parseErrors(), "queue-worker")...)
+			}
+
+			return messages
+		},
+	},
+	{
+		ID:       "OF-CHK-003",
+		Severity: SeverityError,
+		Category: CategoryConfiguration,
+		Summary:  "Function setting could not be parsed",
+		Docs:     docsTimeouts,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			messages := append([]string{}, fn.ParseErrors...)
+			messages = append(messages, fn.Timeout.parseErrors()...)
+
+			if fn.Scaling != nil && len(fn.Scaling.ZeroDuration) > 0 {
+				if _, err := time.ParseDuration(fn.Scaling.ZeroDuration); err != nil {
+					messages = append(messages, fmt.Sprintf("could not parse com.openfaas.scale.zero-duration '%s'", fn.Scaling.ZeroDuration))
+				}
+			}
+
+			return on(messages, fmt.Sprintf("%s.%s", fn.Name, namespace))
+		},
+	},
+}
+
+// on appends the component or function a message applies to
+func on(messages []string, name string) []string {
+	if len(name) == 0 {
+		return messages
+	}
+
+	result := make([]string, 0, len(messages))
+	for _, message := range messages {
+		result = append(result, fmt.Sprintf("%s on %s", message, name))
+	}
+	return result
+}
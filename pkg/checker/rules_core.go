@@ -0,0 +1,311 @@
+package checker
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	docsProduction      = "https://docs.openfaas.com/architecture/production/"
+	docsAsync           = "https://docs.openfaas.com/reference/async/"
+	docsJetStream       = "https://docs.openfaas.com/openfaas-pro/jetstream"
+	docsJetStreamBlog   = "https://www.openfaas.com/blog/jetstream-for-openfaas/"
+	docsAutoscaling     = "https://docs.openfaas.com/architecture/autoscaling/"
+	docsKubernetes      = "https://docs.openfaas.com/deployment/kubernetes/"
+	docsDashboardSigner = "https://docs.openfaas.com/openfaas-pro/dashboard/#create-a-signing-key"
+	docsTimeouts        = "https://docs.openfaas.com/tutorials/expanded-timeouts/"
+)
+
+func init() {
+	Register(coreRules...)
+}
+
+var coreRules = []Rule{
+	{
+		ID:       "OF-QW-001",
+		Severity: SeverityWarning,
+		Category: CategoryTimeouts,
+		Summary:  "JetStream queue-worker ack_wait is outside the recommended range",
+		Docs:     docsJetStream,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			queueWorker := s.Components.QueueWorker
+			if queueWorker == nil || !queueWorker.JetStream {
+				return nil
+			}
+
+			ackWait, err := time.ParseDuration(queueWorker.AckWait)
+			if err != nil {
+				return nil
+			}
+			if ackWait < t.MinAckWait.Duration || ackWait > t.MaxAckWait.Duration {
+				return []string{fmt.Sprintf("queue-worker ack_wait should be between %s and %s as it is extended automatically when using JetStream", t.MinAckWait.Duration, t.MaxAckWait.Duration)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-QW-002",
+		Severity: SeverityError,
+		Category: CategoryTimeouts,
+		Summary:  "JetStream queue-worker upstream_timeout must equal the gateway's upstream_timeout",
+		Docs:     docsTimeouts,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			queueWorker := s.Components.QueueWorker
+			if queueWorker == nil || !queueWorker.JetStream {
+				return nil
+			}
+
+			gwUpstreamTimeout, ok := s.gatewayUpstreamTimeout()
+			if !ok {
+				return nil
+			}
+			queueWorkerUpstreamTimeout, err := queueWorker.Timeout.GetAdditionalTimeout("upstream_timeout")
+			if err != nil {
+				return nil
+			}
+			if queueWorkerUpstreamTimeout != gwUpstreamTimeout {
+				return []string{fmt.Sprintf("queue-worker upstream_timeout (%s) must be equal to gateway.upstream_timeout (%s)", queueWorkerUpstreamTimeout, gwUpstreamTimeout)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-QW-003",
+		Severity: SeverityError,
+		Category: CategoryTimeouts,
+		Summary:  "NATS Streaming queue-worker ack_wait must not exceed the gateway's upstream_timeout",
+		Docs:     docsTimeouts,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			queueWorker := s.Components.QueueWorker
+			if queueWorker == nil || queueWorker.JetStream {
+				return nil
+			}
+
+			gwUpstreamTimeout, ok := s.gatewayUpstreamTimeout()
+			if !ok {
+				return nil
+			}
+			ackWait, err := time.ParseDuration(queueWorker.AckWait)
+			if err != nil {
+				return nil
+			}
+			if ackWait > gwUpstreamTimeout {
+				return []string{fmt.Sprintf("queue-worker ack_wait (%s) must be <= gateway.upstream_timeout when using NATS Streaming (%s)", queueWorker.AckWait, gwUpstreamTimeout)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-QW-004",
+		Severity: SeverityWarning,
+		Category: CategoryDeprecation,
+		Summary:  "NATS Streaming queue-worker is deprecated",
+		Docs:     docsJetStream,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			queueWorker := s.Components.QueueWorker
+			if queueWorker == nil || queueWorker.JetStream {
+				return nil
+			}
+			return []string{"NATS Steaming is deprecated, switch to NATS JetStream ASAP"}
+		},
+	},
+	{
+		ID:       "OF-QW-005",
+		Severity: SeverityInfo,
+		Category: CategoryPerformance,
+		Summary:  "Asynchronous concurrency across all queue-workers is low",
+		Docs:     docsAsync,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			queueWorker := s.Components.QueueWorker
+			if queueWorker == nil {
+				return nil
+			}
+
+			if s.AsyncConcurrency() < t.MinAsyncConcurrency {
+				return []string{fmt.Sprintf("queue-worker maximum concurrency is (%d), this may be too low", s.AsyncConcurrency())}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-QW-006",
+		Severity: SeverityInfo,
+		Category: CategoryPerformance,
+		Summary:  "queue-worker max_inflight is high",
+		Docs:     docsAsync,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			queueWorker := s.Components.QueueWorker
+			if queueWorker == nil {
+				return nil
+			}
+
+			if queueWorker.MaxInflight > t.MaxQueueWorkerInflight {
+				return []string{fmt.Sprintf("queue-worker max_inflight is (%d), this may be too high", queueWorker.MaxInflight)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-QW-007",
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Summary:  "queue-worker is not Highly Available",
+		Docs:     docsProduction,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			queueWorker := s.Components.QueueWorker
+			if queueWorker == nil {
+				return nil
+			}
+
+			if queueWorker.Replicas < t.QueueWorkerReplicas {
+				return []string{fmt.Sprintf("queue-worker replicas want >= %d but got %d, (not Highly Available (HA))", t.QueueWorkerReplicas, queueWorker.Replicas)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-QW-008",
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Summary:  "NATS is deployed by the OpenFaaS chart without persistence",
+		Docs:     docsProduction,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			queueWorker := s.Components.QueueWorker
+			if queueWorker == nil || !queueWorker.InternalNats {
+				return nil
+			}
+			return []string{"Use external NATS to ensure high-availability and persistence"}
+		},
+	},
+	{
+		ID:       "OF-GW-001",
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Summary:  "gateway is not Highly Available",
+		Docs:     docsProduction,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			gateway := s.Components.Gateway
+			if gateway.Replicas < t.GatewayReplicas {
+				return []string{fmt.Sprintf("gateway replicas want >= %d but got %d, (not Highly Available (HA))", t.GatewayReplicas, gateway.Replicas)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-QW-009",
+		Severity: SeverityInfo,
+		Category: CategoryDeprecation,
+		Summary:  "NATS JetStream is not in use",
+		Docs:     docsJetStreamBlog,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			if s.Features(t).JetStream {
+				return nil
+			}
+			return []string{"NATS Streaming will be deprecated and replaced with NATS JetStream"}
+		},
+	},
+	{
+		ID:       "OF-GW-002",
+		Severity: SeverityError,
+		Category: CategoryConfiguration,
+		Summary:  "Istio requires direct_functions on the gateway",
+		Docs:     docsProduction,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			if s.Istio && !s.Components.Gateway.DirectFunctions {
+				return []string{"Istio detected, but direct_functions is disabled"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-GW-003",
+		Severity: SeverityWarning,
+		Category: CategoryConfiguration,
+		Summary:  "Istio requires probe_functions on the gateway",
+		Docs:     docsProduction,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			if s.Istio && !s.Components.Gateway.ProbeFunctions {
+				return []string{"Istio detected, but probe_functions is disabled"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-AS-001",
+		Severity: SeverityError,
+		Category: CategoryScaling,
+		Summary:  "Pro autoscaler requires cluster_role to collect CPU/RAM metrics",
+		Docs:     docsAutoscaling,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			if s.Components.Autoscaler != nil && !s.Components.Controller.ClusterRole {
+				return []string{"Pro autoscaler detected, but cluster_role is disabled - unable to collect CPU/RAM metrics"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-AS-002",
+		Severity: SeverityError,
+		Category: CategoryScaling,
+		Summary:  "Pro autoscaler must run a single replica",
+		Docs:     docsAutoscaling,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			if s.Components.Autoscaler != nil && s.Components.Autoscaler.Replicas > 1 {
+				return []string{"autoscaler replicas should be 1 to prevent double scaling actions"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-CTL-001",
+		Severity: SeverityWarning,
+		Category: CategoryConfiguration,
+		Summary:  "OpenFaaS Pro should use the operator instead of faas-netes",
+		Docs:     docsKubernetes,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			if s.Components.Controller.Mode != "operator" {
+				return []string{"Operator mode is not enabled, OpenFaaS Pro customers should use the OpenFaaS operator"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-GW-004",
+		Severity: SeverityWarning,
+		Category: CategoryScaling,
+		Summary:  "Pro gateway is deployed without the Pro autoscaler",
+		Docs:     docsAutoscaling,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			if s.Components.Gateway.Pro && s.Components.Autoscaler == nil {
+				return []string{"Pro gateway detected, but autoscaler is not enabled"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-CTL-002",
+		Severity: SeverityWarning,
+		Category: CategorySecurity,
+		Summary:  "Functions are not forced to run as a non-root user",
+		Docs:     docsProduction,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			if !s.Components.Controller.SetNonRootUser {
+				return []string{"Non-root flag is not set for the controller/operator"}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-DB-001",
+		Severity: SeverityWarning,
+		Category: CategorySecurity,
+		Summary:  "Dashboard uses auto generated signing keys",
+		Docs:     docsDashboardSigner,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			if s.Components.Dashboard != nil && !s.Components.Dashboard.JWTSecret {
+				return []string{"Dashboard uses auto generated signing keys"}
+			}
+			return nil
+		},
+	},
+}
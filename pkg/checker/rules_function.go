@@ -0,0 +1,209 @@
+package checker
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	docsScaleToZero     = "https://docs.openfaas.com/openfaas-pro/scale-to-zero/"
+	docsReadOnlyRootfs  = "https://docs.openfaas.com/reference/yaml/#function-read-only-root-filesystem"
+	docsMemoryCPULimits = "https://docs.openfaas.com/reference/yaml/#function-memorycpu-limits"
+	docsWatchdog        = "https://docs.openfaas.com/architecture/watchdog/"
+)
+
+func init() {
+	Register(functionRules...)
+}
+
+var functionRules = []Rule{
+	{
+		ID:       "OF-FN-006",
+		Severity: SeverityWarning,
+		Category: CategoryScaling,
+		Summary:  "Function scales to zero sooner than recommended",
+		Docs:     docsScaleToZero,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Scaling == nil || fn.Scaling.GetZeroDuration() == "<not set>" {
+				return nil
+			}
+
+			dur, err := time.ParseDuration(fn.Scaling.GetZeroDuration())
+			min := t.MinScaleToZeroDuration.Duration
+			if err == nil && dur < min {
+				return []string{fmt.Sprintf("%s.%s scales down after %.2f minutes, this may be too soon, %s minutes or higher is recommended", fn.Name, namespace, dur.Minutes(), strconv.FormatFloat(min.Minutes(), 'f', -1, 64))}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-FN-001",
+		Severity: SeverityWarning,
+		Category: CategoryTimeouts,
+		Summary:  "Function read_timeout is not set",
+		Docs:     docsTimeouts,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if len(fn.Timeout.ReadTimeout) == 0 && !fn.Timeout.IsUnresolved("read_timeout") {
+				return []string{fmt.Sprintf("%s.%s read_timeout is not set%s", fn.Name, namespace, watchdogDefault(fn, "read_timeout"))}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-FN-002",
+		Severity: SeverityWarning,
+		Category: CategoryTimeouts,
+		Summary:  "Function write_timeout is not set",
+		Docs:     docsTimeouts,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if len(fn.Timeout.WriteTimeout) == 0 && !fn.Timeout.IsUnresolved("write_timeout") {
+				return []string{fmt.Sprintf("%s.%s write_timeout is not set%s", fn.Name, namespace, watchdogDefault(fn, "write_timeout"))}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-FN-003",
+		Severity: SeverityWarning,
+		Category: CategoryTimeouts,
+		Summary:  "Function exec_timeout is not set",
+		Docs:     docsTimeouts,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if _, ok := fn.Timeout.Additional["exec_timeout"]; !ok && !fn.Timeout.IsUnresolved("exec_timeout") {
+				return []string{fmt.Sprintf("%s.%s exec_timeout is not set%s", fn.Name, namespace, watchdogDefault(fn, "exec_timeout"))}
+			}
+
+			// Both watchdogs treat an exec_timeout of 0 as no limit
+			if execTimeout, err := fn.Timeout.GetAdditionalTimeout("exec_timeout"); err == nil && execTimeout == 0 {
+				return []string{fmt.Sprintf("%s.%s exec_timeout is 0, so executions are not limited", fn.Name, namespace)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-FN-005",
+		Severity: SeverityError,
+		Category: CategoryTimeouts,
+		Summary:  "Function timeout is greater than the gateway's upstream_timeout",
+		Docs:     docsTimeouts,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			gwUpstreamTimeout, ok := s.gatewayUpstreamTimeout()
+			if !ok {
+				return nil
+			}
+
+			var messages []string
+			if readTimeout, err := fn.Timeout.GetReadTimeout(); err == nil && readTimeout > gwUpstreamTimeout {
+				messages = append(messages, fmt.Sprintf("%s.%s read_timeout (%s) is greater than gateway.upstream_timeout (%s)", fn.Name, namespace, fn.Timeout.ReadTimeout, gwUpstreamTimeout))
+			}
+
+			if writeTimeout, err := fn.Timeout.GetWriteTimeout(); err == nil && writeTimeout > gwUpstreamTimeout {
+				messages = append(messages, fmt.Sprintf("%s.%s write_timeout (%s) is greater than gateway.upstream_timeout (%s)", fn.Name, namespace, fn.Timeout.WriteTimeout, gwUpstreamTimeout))
+			}
+
+			if execTimeout, err := fn.Timeout.GetAdditionalTimeout("exec_timeout"); err == nil && execTimeout > gwUpstreamTimeout {
+				messages = append(messages, fmt.Sprintf("%s.%s exec_timeout (%s) is greater than gateway.upstream_timeout (%s)", fn.Name, namespace, execTimeout, gwUpstreamTimeout))
+			}
+
+			return messages
+		},
+	},
+	{
+		ID:       "OF-FN-008",
+		Severity: SeverityWarning,
+		Category: CategoryResources,
+		Summary:  "Function has no memory requests",
+		Docs:     docsMemoryCPULimits,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Requests.Memory == "0" {
+				return []string{fmt.Sprintf("%s.%s no memory requests set", fn.Name, namespace)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-FN-009",
+		Severity: SeverityWarning,
+		Category: CategoryTimeouts,
+		Summary:  "Function exec_timeout is greater than its write_timeout",
+		Docs:     docsTimeouts,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			execTimeout, ok := fn.effectiveTimeout("exec_timeout")
+			if !ok || execTimeout == 0 {
+				return nil
+			}
+
+			writeTimeout, ok := fn.effectiveTimeout("write_timeout")
+			if ok && execTimeout > writeTimeout {
+				return []string{fmt.Sprintf("%s.%s exec_timeout (%s) is greater than write_timeout (%s), the response can not be written after write_timeout", fn.Name, namespace, execTimeout, writeTimeout)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-FN-010",
+		Severity: SeverityError,
+		Category: CategoryConfiguration,
+		Summary:  "Function sets an unknown of-watchdog mode",
+		Docs:     docsWatchdog,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Watchdog.Watchdog != WatchdogOf || len(fn.Watchdog.Mode) == 0 {
+				return nil
+			}
+
+			switch fn.Watchdog.Mode {
+			case "http", "streaming", "serializing", "static":
+				return nil
+			}
+			return []string{fmt.Sprintf("%s.%s of-watchdog mode '%s' is unknown, use http, streaming, serializing or static", fn.Name, namespace, fn.Watchdog.Mode)}
+		},
+	},
+	{
+		ID:       "OF-FN-004",
+		Severity: SeverityInfo,
+		Category: CategoryScaling,
+		Summary:  "No functions in a namespace scale to zero",
+		Docs:     docsScaleToZero,
+		CheckNamespace: func(s *ClusterSnapshot, t Thresholds, namespace string, functions []Function) []string {
+			for _, fn := range functions {
+				if fn.Scaling != nil && fn.Scaling.GetZero() == "true" {
+					return nil
+				}
+			}
+
+			if len(functions) > 0 {
+				return []string{fmt.Sprintf("no functions in namespace %s are configured to scale down, this may be inefficient", namespace)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-FN-007",
+		Severity: SeverityWarning,
+		Category: CategorySecurity,
+		Summary:  "Function does not use a read-only root filesystem",
+		Docs:     docsReadOnlyRootfs,
+		CheckNamespace: func(s *ClusterSnapshot, t Thresholds, namespace string, functions []Function) []string {
+			for _, fn := range functions {
+				if !fn.ReadOnlyRootFilesystem {
+					return []string{fmt.Sprintf("at least one function in namespace %s does not set the file system to read-only", namespace)}
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// watchdogDefault describes the value used for a timeout which is not set,
+// when the function's watchdog is known.
+func watchdogDefault(fn Function, key string) string {
+	d, ok := fn.Watchdog.Watchdog.defaultTimeout(key)
+	if !ok {
+		return ""
+	}
+	if d == 0 {
+		return fmt.Sprintf(", the %s does not limit executions", fn.Watchdog.Watchdog)
+	}
+	return fmt.Sprintf(", the %s default of %s applies", fn.Watchdog.Watchdog, d)
+}
@@ -0,0 +1,100 @@
+package checker
+
+import (
+	"fmt"
+)
+
+const docsHA = "https://docs.openfaas.com/architecture/production/#high-availability"
+
+func init() {
+	Register(haRules...)
+}
+
+// haRules check that the gateway and queue-worker are Highly Available in
+// practice, rather than only by their number of replicas. They are only
+// run for a component with more than one replica.
+var haRules = []Rule{
+	{
+		ID:       "OF-HA-001",
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Summary:  "HA component has no PodDisruptionBudget",
+		Docs:     docsHA,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.haChecks(func(name string, replicas int, ha *HighAvailability) []string {
+				if ha.PodDisruptionBudgets != nil && len(ha.PodDisruptionBudgets) == 0 {
+					return []string{fmt.Sprintf("%s has %d replicas but no PodDisruptionBudget, a node drain can evict all of them at once", name, replicas)}
+				}
+				return nil
+			})
+		},
+	},
+	{
+		ID:       "OF-HA-002",
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Summary:  "HA component has no podAntiAffinity or topologySpreadConstraints",
+		Docs:     docsHA,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.haChecks(func(name string, replicas int, ha *HighAvailability) []string {
+				if !ha.AntiAffinity && !ha.TopologySpread {
+					return []string{fmt.Sprintf("%s has %d replicas but no podAntiAffinity or topologySpreadConstraints, they may be scheduled onto the same node", name, replicas)}
+				}
+				return nil
+			})
+		},
+	},
+	{
+		ID:       "OF-HA-003",
+		Severity: SeverityError,
+		Category: CategoryAvailability,
+		Summary:  "HA component's replicas are all running on one node",
+		Docs:     docsHA,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.haChecks(func(name string, replicas int, ha *HighAvailability) []string {
+				if ha.Running > 1 && ha.Nodes == 1 {
+					return []string{fmt.Sprintf("%s has %d running replicas, but they are all on the same node, so it is not Highly Available", name, ha.Running)}
+				}
+				return nil
+			})
+		},
+	},
+	{
+		ID:       "OF-HA-004",
+		Severity: SeverityInfo,
+		Category: CategoryAvailability,
+		Summary:  "HA component's replicas are all running in one zone",
+		Docs:     docsHA,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.haChecks(func(name string, replicas int, ha *HighAvailability) []string {
+				if ha.Running > 1 && ha.Nodes > 1 && ha.Zones == 1 && ha.ClusterZones > 1 {
+					return []string{fmt.Sprintf("%s has %d running replicas all in one zone, but the cluster's nodes are in %d zones", name, ha.Running, ha.ClusterZones)}
+				}
+				return nil
+			})
+		},
+	},
+}
+
+// haChecks runs check against each component in haComponents which has
+// more than one replica
+func (s *ClusterSnapshot) haChecks(check func(name string, replicas int, ha *HighAvailability) []string) []string {
+	var messages []string
+	for _, name := range haComponents {
+		ha, ok := s.HA[name]
+		if !ok {
+			continue
+		}
+
+		replicas := 0
+		if health := s.CoreHealth[s.deployment(name)]; health != nil {
+			replicas = health.Replicas
+		}
+		if replicas <= 1 {
+			continue
+		}
+
+		messages = append(messages, check(name, replicas, ha)...)
+	}
+	return messages
+}
@@ -0,0 +1,205 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+)
+
+const docsTroubleshooting = "https://docs.openfaas.com/deployment/troubleshooting/"
+
+func init() {
+	Register(healthRules...)
+}
+
+// healthRules check the Pods which are running, rather than the spec of
+// each Deployment. They are skipped when the Pods were not collected.
+var healthRules = []Rule{
+	{
+		ID:       "OF-POD-001",
+		Severity: SeverityError,
+		Category: CategoryAvailability,
+		Summary:  "Core component has fewer replicas available than it wants",
+		Docs:     docsTroubleshooting,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.coreHealth(unavailableReplicas)
+		},
+	},
+	{
+		ID:       "OF-POD-002",
+		Severity: SeverityError,
+		Category: CategoryAvailability,
+		Summary:  "Core component container is failing to start",
+		Docs:     docsTroubleshooting,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.coreHealth(failingContainers)
+		},
+	},
+	{
+		ID:       "OF-POD-003",
+		Severity: SeverityWarning,
+		Category: CategoryResources,
+		Summary:  "Core component container was OOMKilled",
+		Docs:     docsTroubleshooting,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.coreHealth(oomKilledContainers)
+		},
+	},
+	{
+		ID:       "OF-POD-004",
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Summary:  "Core component container has restarted more than recommended",
+		Docs:     docsTroubleshooting,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.coreHealth(func(name string, h *WorkloadHealth) []string {
+				return restartedContainers(name, h, t.MaxContainerRestarts)
+			})
+		},
+	},
+	{
+		ID:       "OF-POD-005",
+		Severity: SeverityError,
+		Category: CategoryAvailability,
+		Summary:  "Core component Pod can not be scheduled",
+		Docs:     docsTroubleshooting,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			return s.coreHealth(unschedulablePods)
+		},
+	},
+	{
+		ID:       "OF-POD-006",
+		Severity: SeverityError,
+		Category: CategoryAvailability,
+		Summary:  "Function has fewer replicas available than it wants",
+		Docs:     docsTroubleshooting,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			return unavailableReplicas(fn.Name+"."+namespace, fn.Health)
+		},
+	},
+	{
+		ID:       "OF-POD-007",
+		Severity: SeverityError,
+		Category: CategoryAvailability,
+		Summary:  "Function container is failing to start",
+		Docs:     docsTroubleshooting,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			return failingContainers(fn.Name+"."+namespace, fn.Health)
+		},
+	},
+	{
+		ID:       "OF-POD-008",
+		Severity: SeverityWarning,
+		Category: CategoryResources,
+		Summary:  "Function container was OOMKilled",
+		Docs:     docsMemoryCPULimits,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			return oomKilledContainers(fn.Name+"."+namespace, fn.Health)
+		},
+	},
+	{
+		ID:       "OF-POD-009",
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Summary:  "Function container has restarted more than recommended",
+		Docs:     docsTroubleshooting,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			return restartedContainers(fn.Name+"."+namespace, fn.Health, t.MaxContainerRestarts)
+		},
+	},
+	{
+		ID:       "OF-POD-010",
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Summary:  "Function Pod can not be scheduled",
+		Docs:     docsTroubleshooting,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			return unschedulablePods(fn.Name+"."+namespace, fn.Health)
+		},
+	},
+}
+
+// coreHealth runs check against each core Deployment in order of name
+func (s *ClusterSnapshot) coreHealth(check func(name string, h *WorkloadHealth) []string) []string {
+	names := make([]string, 0, len(s.CoreHealth))
+	for name := range s.CoreHealth {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var messages []string
+	for _, name := range names {
+		messages = append(messages, check(name, s.CoreHealth[name])...)
+	}
+	return messages
+}
+
+func unavailableReplicas(name string, h *WorkloadHealth) []string {
+	if h == nil || h.Available == nil || *h.Available >= h.Replicas {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s has %d of %d replicas available", name, *h.Available, h.Replicas)}
+}
+
+func failingContainers(name string, h *WorkloadHealth) []string {
+	if h == nil {
+		return nil
+	}
+
+	var messages []string
+	for _, pod := range h.Pods {
+		for _, container := range pod.failing() {
+			message := fmt.Sprintf("%s pod %s container %s is in %s", name, pod.Name, container.Name, container.Waiting)
+			if len(container.LastTermination) > 0 {
+				message += fmt.Sprintf(", it last exited with %s", container.LastTermination)
+			}
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+func oomKilledContainers(name string, h *WorkloadHealth) []string {
+	if h == nil {
+		return nil
+	}
+
+	var messages []string
+	for _, pod := range h.Pods {
+		for _, container := range pod.Containers {
+			if container.LastTermination == "OOMKilled" {
+				messages = append(messages, fmt.Sprintf("%s pod %s container %s was OOMKilled, its memory limit may be too low", name, pod.Name, container.Name))
+			}
+		}
+	}
+	return messages
+}
+
+func restartedContainers(name string, h *WorkloadHealth, max int) []string {
+	if h == nil {
+		return nil
+	}
+
+	var messages []string
+	for _, pod := range h.Pods {
+		for _, container := range pod.Containers {
+			if container.Restarts > max {
+				messages = append(messages, fmt.Sprintf("%s pod %s container %s has restarted %d times, %d or fewer is recommended", name, pod.Name, container.Name, container.Restarts, max))
+			}
+		}
+	}
+	return messages
+}
+
+func unschedulablePods(name string, h *WorkloadHealth) []string {
+	if h == nil {
+		return nil
+	}
+
+	var messages []string
+	for _, pod := range h.Pods {
+		if len(pod.Unschedulable) > 0 {
+			messages = append(messages, fmt.Sprintf("%s pod %s is Pending, %s", name, pod.Name, pod.Unschedulable))
+		}
+	}
+	return messages
+}
@@ -0,0 +1,73 @@
+package checker
+
+import (
+	"fmt"
+)
+
+const docsNamespaces = "https://docs.openfaas.com/reference/namespaces/"
+
+func init() {
+	Register(namespaceRules...)
+}
+
+// namespaceRules compare the function namespaces with those which the
+// controller is able to use. Each finding is about one namespace, which
+// can ignore the rule with IgnoreAnnotation.
+var namespaceRules = []Rule{
+	{
+		ID:       "OF-NS-001",
+		Severity: SeverityError,
+		Category: CategoryConfiguration,
+		Summary:  "Controller's function namespace does not exist",
+		Docs:     docsNamespaces,
+		CheckClusterNamespaces: func(s *ClusterSnapshot, t Thresholds) map[string]string {
+			messages := map[string]string{}
+			for _, namespace := range s.MissingNamespaces {
+				messages[namespace] = fmt.Sprintf("namespace %s does not exist, but is the controller's function_namespace, so functions deployed without a namespace will fail", namespace)
+			}
+			return messages
+		},
+	},
+	{
+		ID:       "OF-NS-002",
+		Severity: SeverityWarning,
+		Category: CategoryConfiguration,
+		Summary:  "Namespace is marked for functions, but cluster_role is disabled",
+		Docs:     docsNamespaces,
+		CheckClusterNamespaces: func(s *ClusterSnapshot, t Thresholds) map[string]string {
+			messages := map[string]string{}
+			for _, namespace := range s.UnselectedNamespaces {
+				messages[namespace] = fmt.Sprintf("namespace %s is marked with openfaas: \"1\", but cluster_role is disabled, so the controller does not manage functions in it", namespace)
+			}
+			return messages
+		},
+	},
+	{
+		ID:       "OF-NS-003",
+		Severity: SeverityError,
+		Category: CategoryConfiguration,
+		Summary:  "Controller can not create Deployments in a function namespace",
+		Docs:     docsNamespaces,
+		CheckClusterNamespaces: func(s *ClusterSnapshot, t Thresholds) map[string]string {
+			messages := map[string]string{}
+			for _, namespace := range s.UnboundNamespaces {
+				messages[namespace] = fmt.Sprintf("namespace %s has no RoleBinding which allows the controller's ServiceAccount %s.%s to create Deployments, so functions can not be deployed to it", namespace, s.Components.Controller.ServiceAccount, s.CoreNamespace)
+			}
+			return messages
+		},
+	},
+	{
+		ID:       "OF-NS-004",
+		Severity: SeverityWarning,
+		Category: CategoryConfiguration,
+		Summary:  "Function namespace is used by more than one installation",
+		Docs:     docsNamespaces,
+		CheckClusterNamespaces: func(s *ClusterSnapshot, t Thresholds) map[string]string {
+			messages := map[string]string{}
+			for namespace, owner := range s.SharedNamespaces {
+				messages[namespace] = fmt.Sprintf("namespace %s is also used by the installation in %s, where its functions are reported, so both controllers manage functions in it", namespace, owner)
+			}
+			return messages
+		},
+	},
+}
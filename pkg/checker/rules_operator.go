@@ -0,0 +1,77 @@
+package checker
+
+import (
+	"fmt"
+)
+
+const docsOperator = "https://docs.openfaas.com/deployment/kubernetes/"
+
+func init() {
+	Register(operatorRules...)
+}
+
+// operatorRules compare the Function custom resources with the Deployments
+// created from them, they only apply when the CRs were read.
+var operatorRules = []Rule{
+	{
+		ID:       "OF-CR-001",
+		Severity: SeverityError,
+		Category: CategoryAvailability,
+		Summary:  "Function custom resource has no Deployment",
+		Docs:     docsOperator,
+		CheckNamespace: func(s *ClusterSnapshot, t Thresholds, namespace string, functions []Function) []string {
+			deployed := map[string]bool{}
+			for _, fn := range s.Functions[namespace] {
+				deployed[fn.Name] = true
+			}
+			// A Deployment which could not be read still exists
+			for _, name := range s.UnreadFunctions[namespace] {
+				deployed[name] = true
+			}
+
+			var messages []string
+			for _, cr := range s.FunctionCRs[namespace] {
+				if !deployed[cr.DeploymentName()] {
+					messages = append(messages, fmt.Sprintf("function CR %s.%s has no Deployment, check the operator's logs", cr.Name, namespace))
+				}
+			}
+			return messages
+		},
+	},
+	{
+		ID:       "OF-CR-002",
+		Severity: SeverityWarning,
+		Category: CategoryConfiguration,
+		Summary:  "Function Deployment has no custom resource in operator mode",
+		Docs:     docsOperator,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			crs, ok := s.FunctionCRs[namespace]
+			if !ok {
+				return nil
+			}
+
+			for _, cr := range crs {
+				if cr.DeploymentName() == fn.Name {
+					return nil
+				}
+			}
+			return []string{fmt.Sprintf("%s.%s has no Function CR, it is not managed by the operator", fn.Name, namespace)}
+		},
+	},
+	{
+		ID:       "OF-CR-003",
+		Severity: SeverityError,
+		Category: CategoryConfiguration,
+		Summary:  "Function custom resource spec is invalid",
+		Docs:     docsOperator,
+		CheckNamespace: func(s *ClusterSnapshot, t Thresholds, namespace string, functions []Function) []string {
+			var messages []string
+			for _, cr := range s.FunctionCRs[namespace] {
+				for _, problem := range cr.validate() {
+					messages = append(messages, fmt.Sprintf("function CR %s.%s %s", cr.Name, namespace, problem))
+				}
+			}
+			return messages
+		},
+	},
+}
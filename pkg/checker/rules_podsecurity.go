@@ -0,0 +1,60 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	docsPodSecurity    = "https://kubernetes.io/docs/concepts/security/pod-security-standards/"
+	docsAutomountToken = "https://kubernetes.io/docs/tasks/configure-pod-container/configure-service-account/#opt-out-of-api-credential-automounting"
+)
+
+func init() {
+	Register(podSecurityRules...)
+}
+
+// podSecurityRules evaluate each function's Pod spec against the Pod
+// Security Standards, which a namespace can enforce with the
+// pod-security.kubernetes.io/enforce label.
+var podSecurityRules = []Rule{
+	{
+		ID:       "OF-PSS-001",
+		Severity: SeverityWarning,
+		Category: CategorySecurity,
+		Summary:  "Function does not pass the baseline Pod Security Standard",
+		Docs:     docsPodSecurity,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.PodSecurity == nil || len(fn.PodSecurity.Baseline) == 0 {
+				return nil
+			}
+			return []string{fmt.Sprintf("%s.%s does not pass the baseline level: %s", fn.Name, namespace, strings.Join(fn.PodSecurity.Baseline, ", "))}
+		},
+	},
+	{
+		ID:       "OF-PSS-002",
+		Severity: SeverityInfo,
+		Category: CategorySecurity,
+		Summary:  "Function does not pass the restricted Pod Security Standard",
+		Docs:     docsPodSecurity,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.PodSecurity == nil || len(fn.PodSecurity.Restricted) == 0 {
+				return nil
+			}
+			return []string{fmt.Sprintf("%s.%s does not pass the restricted level: %s", fn.Name, namespace, strings.Join(fn.PodSecurity.Restricted, ", "))}
+		},
+	},
+	{
+		ID:       "OF-PSS-003",
+		Severity: SeverityInfo,
+		Category: CategorySecurity,
+		Summary:  "Function mounts a service account token",
+		Docs:     docsAutomountToken,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.PodSecurity == nil || !fn.PodSecurity.AutomountServiceAccountToken {
+				return nil
+			}
+			return []string{fmt.Sprintf("%s.%s does not set automountServiceAccountToken to false, so a token for the Kubernetes API is mounted unless its ServiceAccount disables it", fn.Name, namespace)}
+		},
+	},
+}
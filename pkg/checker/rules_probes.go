@@ -0,0 +1,121 @@
+package checker
+
+import (
+	"fmt"
+)
+
+const docsHealthChecks = "https://docs.openfaas.com/reference/workloads/#custom-http-health-checks"
+
+func init() {
+	Register(probeRules...)
+}
+
+// probeRules check the liveness and readiness probes which faas-netes or
+// the operator add to each function, and which may have been edited or
+// removed since.
+var probeRules = []Rule{
+	{
+		ID:       "OF-PRB-001",
+		Severity: SeverityWarning,
+		Category: CategoryAvailability,
+		Summary:  "Function has no readiness probe",
+		Docs:     docsHealthChecks,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Probes != nil && fn.Probes.Readiness == nil {
+				return []string{fmt.Sprintf("%s.%s has no readiness probe, requests can be sent to a new replica before its watchdog is listening", fn.Name, namespace)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-PRB-002",
+		Severity: SeverityInfo,
+		Category: CategoryAvailability,
+		Summary:  "Function has no liveness probe",
+		Docs:     docsHealthChecks,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Probes != nil && fn.Probes.Liveness == nil {
+				return []string{fmt.Sprintf("%s.%s has no liveness probe, a replica which stops responding will not be restarted", fn.Name, namespace)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-PRB-003",
+		Severity: SeverityWarning,
+		Category: CategoryConfiguration,
+		Summary:  "Function probe path does not match its annotation",
+		Docs:     docsHealthChecks,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Probes == nil {
+				return nil
+			}
+
+			var messages []string
+			if p := fn.Probes.Liveness; p != nil && p.Type == "http" && len(fn.Probes.HealthPath) > 0 && p.Path != fn.Probes.HealthPath {
+				messages = append(messages, fmt.Sprintf("%s.%s liveness probe requests %s, but %s is %s", fn.Name, namespace, p.Path, HealthPathAnnotation, fn.Probes.HealthPath))
+			}
+			if p := fn.Probes.Readiness; p != nil && p.Type == "http" && len(fn.Probes.ReadyPath) > 0 && p.Path != fn.Probes.ReadyPath {
+				messages = append(messages, fmt.Sprintf("%s.%s readiness probe requests %s, but %s is %s", fn.Name, namespace, p.Path, ReadyPathAnnotation, fn.Probes.ReadyPath))
+			}
+			return messages
+		},
+	},
+	{
+		ID:       "OF-PRB-004",
+		Severity: SeverityWarning,
+		Category: CategoryTimeouts,
+		Summary:  "Function readiness probe is slower than the gateway's upstream_timeout when scaling from zero",
+		Docs:     docsHealthChecks,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			// With probe_functions, the gateway probes the function itself
+			// rather than waiting for the Pod to be marked as ready
+			if s.Components.Gateway.ProbeFunctions {
+				return nil
+			}
+			if fn.Scaling == nil || fn.Scaling.GetZero() != "true" || fn.Probes == nil || fn.Probes.Readiness == nil {
+				return nil
+			}
+
+			gwUpstreamTimeout, ok := s.gatewayUpstreamTimeout()
+			if !ok {
+				return nil
+			}
+
+			p := fn.Probes.Readiness
+			if p.readyAfter() >= gwUpstreamTimeout {
+				return []string{fmt.Sprintf("%s.%s scales to zero and its readiness probe can take %s (initialDelaySeconds: %d, periodSeconds: %d) to pass, which is not less than gateway.upstream_timeout (%s), so requests which wake it can time out", fn.Name, namespace, p.readyAfter(), p.InitialDelaySeconds, p.PeriodSeconds, gwUpstreamTimeout)}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-PRB-005",
+		Severity: SeverityInfo,
+		Category: CategoryTimeouts,
+		Summary:  "Function liveness probe restarts it sooner than its exec_timeout",
+		Docs:     docsHealthChecks,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			// The watchdog answers its own health path, any other path is
+			// answered by the function, which may be busy with a request
+			if fn.Probes == nil {
+				return nil
+			}
+
+			p := fn.Probes.Liveness
+			if p == nil || p.Type != "http" || p.Path == watchdogHealthPath {
+				return nil
+			}
+
+			execTimeout, ok := fn.effectiveTimeout("exec_timeout")
+			if !ok || execTimeout == 0 {
+				return nil
+			}
+
+			if p.failsAfter() < execTimeout {
+				return []string{fmt.Sprintf("%s.%s liveness probe restarts it after failing for %s (periodSeconds: %d, failureThreshold: %d), which is less than exec_timeout (%s), a replica which is too busy to answer %s will be restarted during an execution", fn.Name, namespace, p.failsAfter(), p.PeriodSeconds, p.FailureThreshold, execTimeout, p.Path)}
+			}
+			return nil
+		},
+	},
+}
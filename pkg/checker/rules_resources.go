@@ -0,0 +1,135 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	Register(resourceRules...)
+}
+
+// resourceRules check the requests and limits of functions and of the
+// core components, in addition to OF-FN-008 for memory requests.
+var resourceRules = []Rule{
+	{
+		ID:       "OF-RES-001",
+		Severity: SeverityError,
+		Category: CategoryResources,
+		Summary:  "Function requests are greater than its limits",
+		Docs:     docsMemoryCPULimits,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Requests == nil || fn.Limits == nil {
+				return nil
+			}
+
+			var messages []string
+			for _, r := range []struct {
+				name       string
+				req, limit string
+			}{
+				{"memory", fn.Requests.Memory, fn.Limits.Memory},
+				{"cpu", fn.Requests.CPU, fn.Limits.CPU},
+			} {
+				req, hasReq := quantity(r.req)
+				limit, hasLimit := quantity(r.limit)
+				if hasReq && hasLimit && req.Cmp(limit) > 0 {
+					messages = append(messages, fmt.Sprintf("%s.%s %s requests (%s) are greater than its limit (%s), Kubernetes will not create its Pods", fn.Name, namespace, r.name, r.req, r.limit))
+				}
+			}
+			return messages
+		},
+	},
+	{
+		ID:       "OF-RES-002",
+		Severity: SeverityWarning,
+		Category: CategoryResources,
+		Summary:  "Function has no memory limit",
+		Docs:     docsMemoryCPULimits,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Limits == nil {
+				return nil
+			}
+			if _, ok := quantity(fn.Limits.Memory); !ok {
+				return []string{fmt.Sprintf("%s.%s memory limit is %s, it can use all of the memory on its node", fn.Name, namespace, fn.Limits.GetMemory())}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-RES-003",
+		Severity: SeverityInfo,
+		Category: CategoryResources,
+		Summary:  "Function has a CPU limit but no CPU request",
+		Docs:     docsMemoryCPULimits,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Requests == nil || fn.Limits == nil {
+				return nil
+			}
+			_, hasReq := quantity(fn.Requests.CPU)
+			if _, hasLimit := quantity(fn.Limits.CPU); hasLimit && !hasReq {
+				return []string{fmt.Sprintf("%s.%s cpu limit is %s but its cpu requests are %s, Kubernetes will request the whole limit for each replica", fn.Name, namespace, fn.Limits.GetCpu(), fn.Requests.GetCpu())}
+			}
+			return nil
+		},
+	},
+	{
+		ID:       "OF-RES-004",
+		Severity: SeverityWarning,
+		Category: CategoryResources,
+		Summary:  "Function requests are lower than recommended",
+		Docs:     docsMemoryCPULimits,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if fn.Requests == nil {
+				return nil
+			}
+
+			var messages []string
+			if memory, ok := quantity(fn.Requests.Memory); ok && memory.Cmp(t.MinMemoryRequest) < 0 {
+				messages = append(messages, fmt.Sprintf("%s.%s memory requests (%s) are lower than %s, its replicas can be packed onto a node which doesn't have the memory to run them", fn.Name, namespace, fn.Requests.GetMemory(), t.MinMemoryRequest.String()))
+			}
+			if cpu, ok := quantity(fn.Requests.CPU); ok && cpu.Cmp(t.MinCPURequest) < 0 {
+				messages = append(messages, fmt.Sprintf("%s.%s cpu requests (%s) are lower than %s, it will be starved of CPU on a busy node", fn.Name, namespace, fn.Requests.GetCpu(), t.MinCPURequest.String()))
+			}
+			return messages
+		},
+	},
+	{
+		ID:       "OF-RES-005",
+		Severity: SeverityWarning,
+		Category: CategoryResources,
+		Summary:  "Core component has no requests or memory limit",
+		Docs:     docsProduction,
+		CheckCluster: func(s *ClusterSnapshot, t Thresholds) []string {
+			var messages []string
+			for _, name := range coreContainers {
+				r, ok := s.Components.Resources[name]
+				if !ok {
+					continue
+				}
+
+				// A CPU limit is not recommended, since it throttles
+				// the component under load
+				var missing []string
+				if _, ok := quantity(r.Requests.Memory); !ok {
+					missing = append(missing, "memory requests")
+				}
+				if _, ok := quantity(r.Requests.CPU); !ok {
+					missing = append(missing, "cpu requests")
+				}
+				if _, ok := quantity(r.Limits.Memory); !ok {
+					missing = append(missing, "memory limit")
+				}
+				if len(missing) > 0 {
+					list := missing[len(missing)-1]
+					if len(missing) > 1 {
+						list = strings.Join(missing[:len(missing)-1], ", ") + " or " + list
+					}
+					messages = append(messages, fmt.Sprintf("%s has no %s (requests: %s memory, %s cpu, limits: %s memory)", name, list,
+						r.Requests.GetMemory(), r.Requests.GetCpu(), r.Limits.GetMemory()))
+				}
+			}
+			return messages
+		},
+	},
+}
@@ -0,0 +1,61 @@
+package checker
+
+import (
+	"fmt"
+)
+
+func init() {
+	Register(scalerRules...)
+}
+
+// scalerRules find other autoscalers which target a function as well as
+// the OpenFaaS autoscaler, they are only collected when it is deployed.
+var scalerRules = []Rule{
+	{
+		ID:       "OF-AS-003",
+		Severity: SeverityError,
+		Category: CategoryScaling,
+		Summary:  "Function is scaled by a HorizontalPodAutoscaler or KEDA ScaledObject and the OpenFaaS autoscaler",
+		Docs:     docsAutoscaling,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if s.Components.Autoscaler == nil {
+				return nil
+			}
+
+			labels := "its default scaling configuration"
+			if fn.Scaling != nil {
+				labels = "its com.openfaas.scale labels"
+			}
+
+			var messages []string
+			for _, scaler := range fn.ExternalScalers {
+				if scaler.Kind == KindVerticalPodAutoscaler {
+					continue
+				}
+				messages = append(messages, fmt.Sprintf("%s.%s is scaled by %s/%s and by the OpenFaaS autoscaler using %s, they will both change its replicas", fn.Name, namespace, scaler.Kind, scaler.Name, labels))
+			}
+			return messages
+		},
+	},
+	{
+		ID:       "OF-AS-004",
+		Severity: SeverityWarning,
+		Category: CategoryScaling,
+		Summary:  "Function is resized by a VerticalPodAutoscaler while the OpenFaaS autoscaler scales it",
+		Docs:     docsAutoscaling,
+		CheckFunction: func(s *ClusterSnapshot, t Thresholds, namespace string, fn Function) []string {
+			if s.Components.Autoscaler == nil {
+				return nil
+			}
+
+			var messages []string
+			for _, scaler := range fn.ExternalScalers {
+				if scaler.Kind != KindVerticalPodAutoscaler || scaler.UpdateMode == "Off" {
+					continue
+				}
+				messages = append(messages, fmt.Sprintf("%s.%s is resized by %s/%s (updateMode: %s), which evicts its Pods to change their requests while the OpenFaaS autoscaler scales them", fn.Name, namespace, scaler.Kind, scaler.Name, scaler.UpdateMode))
+			}
+			return messages
+		},
+	},
+}
@@ -0,0 +1,94 @@
+package checker
+
+import (
+	"regexp"
+	"testing"
+)
+
+func Test_Rules_AreWellFormed(t *testing.T) {
+	idFormat := regexp.MustCompile(`^OF-[A-Z]+-[0-9]{3}$`)
+
+	for _, rule := range Rules() {
+		if !idFormat.MatchString(rule.ID) {
+			t.Errorf("rule %q does not match the format OF-XX-000", rule.ID)
+		}
+
+		switch rule.Severity {
+		case SeverityInfo, SeverityWarning, SeverityError:
+		default:
+			t.Errorf("rule %s has an unknown severity: %q", rule.ID, rule.Severity)
+		}
+
+		if len(rule.Category) == 0 || len(rule.Summary) == 0 {
+			t.Errorf("rule %s needs a category and summary", rule.ID)
+		}
+
+		checks := 0
+		if rule.CheckCluster != nil {
+			checks++
+		}
+		if rule.CheckClusterNamespaces != nil {
+			checks++
+		}
+		if rule.CheckNamespace != nil {
+			checks++
+		}
+		if rule.CheckFunction != nil {
+			checks++
+		}
+		if checks != 1 {
+			t.Errorf("rule %s must set exactly one check function, got %d", rule.ID, checks)
+		}
+	}
+}
+
+func Test_Register_PanicsOnDuplicateID(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("want a panic when registering OF-GW-001 twice")
+		}
+	}()
+
+	Register(Rule{ID: "OF-GW-001"})
+}
+
+func Test_LookupRule(t *testing.T) {
+	rule, ok := LookupRule("OF-FN-005")
+	if !ok {
+		t.Fatalf("want OF-FN-005 to be registered")
+	}
+
+	if rule.Severity != SeverityError || rule.Category != CategoryTimeouts {
+		t.Errorf("want an error in the timeouts category, got: %s %s", rule.Severity, rule.Category)
+	}
+}
+
+func Test_Severity_AtLeast(t *testing.T) {
+	cases := []struct {
+		severity Severity
+		min      Severity
+		want     bool
+	}{
+		{SeverityInfo, SeverityWarning, false},
+		{SeverityWarning, SeverityWarning, true},
+		{SeverityError, SeverityWarning, true},
+		{SeverityWarning, SeverityError, false},
+		{SeverityError, SeverityError, true},
+	}
+
+	for _, c := range cases {
+		if got := c.severity.AtLeast(c.min); got != c.want {
+			t.Errorf("%s.AtLeast(%s) want %v, got %v", c.severity, c.min, c.want, got)
+		}
+	}
+}
+
+func Test_ParseSeverity(t *testing.T) {
+	if _, err := ParseSeverity("warning"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if _, err := ParseSeverity("critical"); err == nil {
+		t.Errorf("want an error for an unknown severity")
+	}
+}
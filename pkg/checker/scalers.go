@@ -0,0 +1,184 @@
+package checker
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// ScaledObjectsResource are KEDA's ScaledObjects
+	ScaledObjectsResource = schema.GroupVersionResource{
+		Group:    "keda.sh",
+		Version:  "v1alpha1",
+		Resource: "scaledobjects",
+	}
+
+	// VerticalPodAutoscalersResource are the Vertical Pod Autoscaler's
+	// custom resources
+	VerticalPodAutoscalersResource = schema.GroupVersionResource{
+		Group:    "autoscaling.k8s.io",
+		Version:  "v1",
+		Resource: "verticalpodautoscalers",
+	}
+)
+
+// Kinds of ExternalScaler
+const (
+	KindHorizontalPodAutoscaler = "HorizontalPodAutoscaler"
+	KindScaledObject            = "ScaledObject"
+	KindVerticalPodAutoscaler   = "VerticalPodAutoscaler"
+)
+
+// ExternalScaler is an object other than the OpenFaaS autoscaler which
+// changes the replicas or resources of a function's Deployment
+type ExternalScaler struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+
+	// UpdateMode is only set for a VerticalPodAutoscaler, "Off" only
+	// gives recommendations
+	UpdateMode string `json:"updateMode,omitempty"`
+}
+
+// collectScalers finds the HorizontalPodAutoscalers, KEDA ScaledObjects
+// and VerticalPodAutoscalers which target function Deployments. KEDA and
+// the VPA are only read when their CRDs are installed and a dynamic
+// client was given.
+func (c *Collector) collectScalers(ctx context.Context, snapshot *ClusterSnapshot) {
+	keda := c.dynamic != nil && c.servesResource(snapshot, ScaledObjectsResource)
+	vpa := c.dynamic != nil && c.servesResource(snapshot, VerticalPodAutoscalersResource)
+
+	for _, namespace := range snapshot.FunctionNamespaces {
+		functions := snapshot.Functions[namespace]
+		if len(functions) == 0 {
+			continue
+		}
+
+		scalers := map[string][]ExternalScaler{}
+
+		hpas, err := c.horizontalPodAutoscalers(ctx, namespace)
+		if err != nil {
+			snapshot.addError("could not list horizontalpodautoscalers in namespace %s: %s", namespace, err)
+		}
+		for target, names := range hpas {
+			for _, name := range names {
+				scalers[target] = append(scalers[target], ExternalScaler{Kind: KindHorizontalPodAutoscaler, Name: name})
+			}
+		}
+
+		if keda {
+			list, err := c.dynamic.Resource(ScaledObjectsResource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				snapshot.addError("could not list scaledobjects in namespace %s: %s", namespace, err)
+			} else {
+				for _, item := range list.Items {
+					// KEDA defaults the target's kind to a Deployment
+					if target, ok := scaleTarget(item, "scaleTargetRef", "Deployment"); ok {
+						scalers[target] = append(scalers[target], ExternalScaler{Kind: KindScaledObject, Name: item.GetName()})
+					}
+				}
+			}
+		}
+
+		if vpa {
+			list, err := c.dynamic.Resource(VerticalPodAutoscalersResource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				snapshot.addError("could not list verticalpodautoscalers in namespace %s: %s", namespace, err)
+			} else {
+				for _, item := range list.Items {
+					target, ok := scaleTarget(item, "targetRef", "")
+					if !ok {
+						continue
+					}
+					mode, _, _ := unstructured.NestedString(item.Object, "spec", "updatePolicy", "updateMode")
+					if len(mode) == 0 {
+						mode = "Auto"
+					}
+					scalers[target] = append(scalers[target], ExternalScaler{Kind: KindVerticalPodAutoscaler, Name: item.GetName(), UpdateMode: mode})
+				}
+			}
+		}
+
+		for i := range functions {
+			functions[i].ExternalScalers = scalers[functions[i].Name]
+		}
+	}
+}
+
+// horizontalPodAutoscalers returns the names of the HPAs in a namespace
+// by the Deployment they target. autoscaling/v2 is only served from
+// Kubernetes 1.23, so older clusters are read through autoscaling/v1, and
+// when neither is served there are none.
+func (c *Collector) horizontalPodAutoscalers(ctx context.Context, namespace string) (map[string][]string, error) {
+	targets := map[string][]string{}
+	add := func(meta metav1.ObjectMeta, kind, name string) {
+		// KEDA creates an HPA for each ScaledObject, which is reported as
+		// the ScaledObject
+		if _, ok := meta.Labels["scaledobject.keda.sh/name"]; ok {
+			return
+		}
+		if kind == "Deployment" {
+			targets[name] = append(targets[name], meta.Name)
+		}
+	}
+
+	list, err := c.client.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for _, hpa := range list.Items {
+			add(hpa.ObjectMeta, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name)
+		}
+		return targets, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	v1List, err := c.client.AutoscalingV1().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, hpa := range v1List.Items {
+		add(hpa.ObjectMeta, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name)
+	}
+	return targets, nil
+}
+
+// scaleTarget returns the name of the Deployment targeted by the
+// reference at spec.<field>, when its kind is not set defaultKind is
+// assumed
+func scaleTarget(item unstructured.Unstructured, field, defaultKind string) (string, bool) {
+	kind, _, _ := unstructured.NestedString(item.Object, "spec", field, "kind")
+	if len(kind) == 0 {
+		kind = defaultKind
+	}
+	name, _, _ := unstructured.NestedString(item.Object, "spec", field, "name")
+
+	return name, kind == "Deployment" && len(name) > 0
+}
+
+// servesResource returns true when the API server serves a resource, such
+// as a CRD which may not be installed. Errors other than the group not
+// being found are recorded.
+func (c *Collector) servesResource(snapshot *ClusterSnapshot, gvr schema.GroupVersionResource) bool {
+	resources, err := c.client.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			snapshot.addError("could not discover %s: %s", gvr.GroupVersion(), err)
+		}
+		return false
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == gvr.Resource {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+// Package checker collects the configuration of an OpenFaaS installation
+// from Kubernetes and analyses it for common problems.
+package checker
+
+// ClusterSnapshot is the configuration of OpenFaaS and its functions at
+// the time it was collected.
+type ClusterSnapshot struct {
+	KubernetesVersion  string                `json:"kubernetesVersion"`
+	CoreNamespace      string                `json:"coreNamespace"`
+	Components         Components            `json:"components"`
+	FunctionNamespaces []string              `json:"functionNamespaces"`
+	Functions          map[string][]Function `json:"functions"`
+
+	// OtherWorkloads are the Deployments in each function namespace which
+	// are not functions, they are not checked
+	OtherWorkloads map[string][]Workload `json:"otherWorkloads,omitempty"`
+
+	// UnreadFunctions are the function Deployments in each function
+	// namespace which could not be read, they are reported as errors
+	UnreadFunctions map[string][]string `json:"unreadFunctions,omitempty"`
+
+	// FunctionCRs are the Function custom resources in each function
+	// namespace, they are only read in operator mode
+	FunctionCRs map[string][]FunctionCR `json:"functionCRs,omitempty"`
+
+	// CoreHealth is the state of the Pods of each core Deployment by name
+	CoreHealth map[string]*WorkloadHealth `json:"coreHealth,omitempty"`
+
+	// HA is how the gateway and queue-worker are protected from disruption
+	// and spread across nodes, by component name
+	HA map[string]*HighAvailability `json:"ha,omitempty"`
+
+	// MissingNamespaces are the controller's own function namespace when
+	// it does not exist, the namespaces could not be listed otherwise
+	MissingNamespaces []string `json:"missingNamespaces,omitempty"`
+
+	// UnselectedNamespaces are marked for functions, but the controller
+	// only uses its own namespace since it does not run with cluster_role
+	UnselectedNamespaces []string `json:"unselectedNamespaces,omitempty"`
+
+	// UnboundNamespaces are function namespaces which have no RoleBinding
+	// for the controller's ServiceAccount, it is nil when the bindings
+	// were not read
+	UnboundNamespaces []string `json:"unboundNamespaces,omitempty"`
+
+	// SharedNamespaces are function namespaces which this installation's
+	// controller also uses, by the core namespace of the installation they
+	// are reported under
+	SharedNamespaces map[string]string `json:"sharedNamespaces,omitempty"`
+
+	// IgnoredRules are the rule IDs ignored by each namespace through
+	// IgnoreAnnotation
+	IgnoredRules map[string][]string `json:"ignoredRules,omitempty"`
+
+	// Istio is true when the istio-system namespace exists
+	Istio bool `json:"istio"`
+
+	// FunctionBuilder is true when the pro-builder is deployed
+	FunctionBuilder bool `json:"functionBuilder"`
+
+	// Errors are the API requests which failed during collection, such
+	// as a list which was denied by RBAC. The rest of the snapshot is
+	// still collected and the errors are reported as findings.
+	Errors []string `json:"errors,omitempty"`
+
+	// ParseErrors are the settings of the core components which could not
+	// be parsed, the setting keeps its zero value.
+	ParseErrors []string `json:"parseErrors,omitempty"`
+}
+
+// Workload is a Deployment in a function namespace which was not created
+// for a function, such as a database
+type Workload struct {
+	Name     string   `json:"name"`
+	Replicas int      `json:"replicas"`
+	Images   []string `json:"images,omitempty"`
+}
+
+// Components are the core OpenFaaS components, optional components
+// are nil when they were not found.
+type Components struct {
+	Gateway     Gateway      `json:"gateway"`
+	Controller  Controller   `json:"controller"`
+	QueueWorker *QueueWorker `json:"queueWorker,omitempty"`
+	Autoscaler  *Autoscaler  `json:"autoscaler,omitempty"`
+	Dashboard   *Dashboard   `json:"dashboard,omitempty"`
+
+	// Resources are the requests and limits of the core components'
+	// containers by name, such as gateway and faas-netes
+	Resources map[string]*ContainerResources `json:"resources,omitempty"`
+}
+
+type Gateway struct {
+	// Deployment is the name of the gateway's Deployment, which is found
+	// by its containers rather than its name
+	Deployment string `json:"deployment,omitempty"`
+
+	Image           string   `json:"image"`
+	Replicas        int      `json:"replicas"`
+	Timeout         *Timeout `json:"timeout"`
+	Pro             bool     `json:"pro"`
+	DirectFunctions bool     `json:"directFunctions"`
+	ProbeFunctions  bool     `json:"probeFunctions"`
+}
+
+// Controller is faas-netes or the operator, which runs in the
+// gateway's Pod.
+type Controller struct {
+	Mode           string   `json:"mode"`
+	Image          string   `json:"image"`
+	Timeout        *Timeout `json:"timeout"`
+	SetNonRootUser bool     `json:"setNonRootUser"`
+	ClusterRole    bool     `json:"clusterRole"`
+
+	// FunctionNamespace is the controller's function_namespace, which is
+	// empty when it is not set
+	FunctionNamespace string `json:"functionNamespace,omitempty"`
+
+	// ServiceAccount is the ServiceAccount of the gateway's Pod, which the
+	// controller uses to manage functions
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+}
+
+type QueueWorker struct {
+	Image        string   `json:"image"`
+	Replicas     int      `json:"replicas"`
+	AckWait      string   `json:"ackWait"`
+	MaxInflight  int      `json:"maxInflight"`
+	Timeout      *Timeout `json:"timeout"`
+	JetStream    bool     `json:"jetstream"`
+	InternalNats bool     `json:"internalNats"`
+}
+
+type Autoscaler struct {
+	Image    string `json:"image"`
+	Replicas int    `json:"replicas"`
+}
+
+type Dashboard struct {
+	Image     string `json:"image"`
+	JWTSecret bool   `json:"jwtSecret"`
+}
+
+// Features summarises which OpenFaaS features are in use
+type Features struct {
+	Async              bool `json:"async"`
+	ProGateway         bool `json:"proGateway"`
+	HAGateway          bool `json:"haGateway"`
+	OperatorMode       bool `json:"operatorMode"`
+	Autoscaler         bool `json:"autoscaler"`
+	Dashboard          bool `json:"dashboard"`
+	JetStream          bool `json:"jetstream"`
+	Istio              bool `json:"istio"`
+	FunctionBuilder    bool `json:"functionBuilder"`
+	MultipleNamespaces bool `json:"multipleNamespaces"`
+}
+
+// Features returns the features detected in the snapshot, the gateway is
+// Highly Available with at least the thresholds' number of replicas
+func (s *ClusterSnapshot) Features(t Thresholds) Features {
+	c := s.Components
+	return Features{
+		Async:              c.QueueWorker != nil,
+		ProGateway:         c.Gateway.Pro,
+		HAGateway:          s.highlyAvailable("gateway", c.Gateway.Replicas, t.GatewayReplicas),
+		OperatorMode:       c.Controller.Mode == "operator",
+		Autoscaler:         c.Autoscaler != nil,
+		Dashboard:          c.Dashboard != nil,
+		JetStream:          c.QueueWorker != nil && c.QueueWorker.JetStream,
+		Istio:              s.Istio,
+		FunctionBuilder:    s.FunctionBuilder,
+		MultipleNamespaces: len(s.FunctionNamespaces) > 0,
+	}
+}
+
+// AsyncConcurrency is the number of asynchronous invocations which can
+// be processed at once across all queue-worker replicas.
+func (s *ClusterSnapshot) AsyncConcurrency() int {
+	if s.Components.QueueWorker == nil {
+		return 0
+	}
+	return s.Components.QueueWorker.Replicas * s.Components.QueueWorker.MaxInflight
+}
+
+// TotalFunctions is the number of functions in all function namespaces
+func (s *ClusterSnapshot) TotalFunctions() int {
+	total := 0
+	for _, namespace := range s.FunctionNamespaces {
+		total += len(s.Functions[namespace])
+	}
+	return total
+}
@@ -0,0 +1,201 @@
+package checker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultFunctionNamespace is used for functions in a stack.yml which do
+// not set a namespace, as it is by the gateway.
+const defaultFunctionNamespace = "openfaas-fn"
+
+// stack is the subset of a faas-cli stack.yml which is checked, any other
+// fields are ignored.
+//
+// https://docs.openfaas.com/reference/yaml/
+type stack struct {
+	Functions map[string]stackFunction `json:"functions"`
+}
+
+type stackFunction struct {
+	Namespace              string                 `json:"namespace"`
+	Environment            map[string]interface{} `json:"environment"`
+	EnvironmentFile        []string               `json:"environment_file"`
+	Labels                 map[string]interface{} `json:"labels"`
+	Annotations            map[string]interface{} `json:"annotations"`
+	Limits                 *stackResources        `json:"limits"`
+	Requests               *stackResources        `json:"requests"`
+	ReadOnlyRootFilesystem bool                   `json:"readonly_root_filesystem"`
+}
+
+type stackResources struct {
+	Memory string `json:"memory"`
+	CPU    string `json:"cpu"`
+}
+
+// stackEnvironmentFile is a file listed in environment_file
+type stackEnvironmentFile struct {
+	Environment map[string]interface{} `json:"environment"`
+}
+
+// ReadStack reads the functions from a faas-cli stack.yml into a snapshot
+// so that they can be checked with AnalyzeFunctions before they are
+// deployed. Files listed in environment_file are read relative to the
+// stack.yml. The core components of the snapshot are empty.
+func ReadStack(path string) (*ClusterSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s stack
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	snapshot := &ClusterSnapshot{
+		Components: Components{
+			Gateway: Gateway{
+				Timeout: NewTimeout(),
+			},
+			Controller: Controller{
+				Timeout: NewTimeout(),
+			},
+		},
+		Functions: make(map[string][]Function),
+	}
+
+	names := make([]string, 0, len(s.Functions))
+	for name := range s.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		spec := s.Functions[name]
+
+		fn, err := readStackFunction(name, spec, filepath.Dir(path))
+		if err != nil {
+			return nil, err
+		}
+
+		namespace := spec.Namespace
+		if len(namespace) == 0 {
+			namespace = defaultFunctionNamespace
+		}
+		if _, ok := snapshot.Functions[namespace]; !ok {
+			snapshot.FunctionNamespaces = append(snapshot.FunctionNamespaces, namespace)
+		}
+		snapshot.Functions[namespace] = append(snapshot.Functions[namespace], fn)
+	}
+	sort.Strings(snapshot.FunctionNamespaces)
+
+	return snapshot, nil
+}
+
+func readStackFunction(name string, spec stackFunction, dir string) (Function, error) {
+	fn := Function{
+		Name:                   name,
+		Timeout:                NewTimeout(),
+		ReadOnlyRootFilesystem: spec.ReadOnlyRootFilesystem,
+	}
+
+	// As with faas-cli, environment_file is applied first and is
+	// overridden by environment
+	var vars []envVar
+	for _, file := range spec.EnvironmentFile {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return Function{}, fmt.Errorf("unable to read environment_file for %s: %w", name, err)
+		}
+
+		var envFile stackEnvironmentFile
+		if err := yaml.Unmarshal(data, &envFile); err != nil {
+			return Function{}, fmt.Errorf("unable to parse %s: %w", file, err)
+		}
+		vars = append(vars, stackEnv(envFile.Environment, file)...)
+	}
+	vars = append(vars, stackEnv(spec.Environment, envSourceLiteral)...)
+	fn.readEnv(vars)
+
+	labels := map[string]string{}
+	for key, value := range spec.Labels {
+		labels[key] = stackValue(value)
+	}
+	fn.readLabels(labels)
+
+	annotations := map[string]string{}
+	for key, value := range spec.Annotations {
+		annotations[key] = stackValue(value)
+	}
+	fn.IgnoredRules = parseIgnoreAnnotation(annotations)
+
+	fn.Requests = fn.stackResources("requests", spec.Requests)
+	fn.Limits = fn.stackResources("limits", spec.Limits)
+
+	return fn, nil
+}
+
+// stackResources converts requests or limits in the same way as they are
+// read from a Deployment, where "0" is used when a value is not set.
+func (f *Function) stackResources(kind string, r *stackResources) *FunctionResources {
+	resources := &FunctionResources{Memory: "0", CPU: "0"}
+	if r == nil {
+		return resources
+	}
+
+	for _, v := range []struct {
+		name  string
+		value string
+		dest  *string
+	}{
+		{"memory", r.Memory, &resources.Memory},
+		{"cpu", r.CPU, &resources.CPU},
+	} {
+		if len(v.value) == 0 {
+			continue
+		}
+		q, err := resource.ParseQuantity(v.value)
+		if err != nil {
+			f.ParseErrors = append(f.ParseErrors, fmt.Sprintf("could not parse %s %s '%s'", kind, v.name, v.value))
+			continue
+		}
+		*v.dest = q.String()
+	}
+
+	return resources
+}
+
+func stackEnv(environment map[string]interface{}, source string) []envVar {
+	keys := make([]string, 0, len(environment))
+	for key := range environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	vars := make([]envVar, 0, len(keys))
+	for _, key := range keys {
+		vars = append(vars, envVar{Name: key, Value: stackValue(environment[key]), Source: source})
+	}
+	return vars
+}
+
+// stackValue formats a YAML scalar, since values such as read_timeout: 60
+// or com.openfaas.scale.zero: true are not quoted in most stack.yml files
+func stackValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,148 @@
+package checker
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const stackYAML = `version: 1.0
+provider:
+  name: openfaas
+functions:
+  resize:
+    lang: golang-middleware
+    image: alexellis2/resize:latest
+    environment:
+      read_timeout: 90
+      exec_timeout: 2m
+    labels:
+      com.openfaas.scale.zero: true
+      com.openfaas.scale.zero-duration: 2m
+    limits:
+      memory: 1Gi
+  env:
+    image: ghcr.io/openfaas/alpine:latest
+    namespace: staging-fn
+    environment_file:
+      - timeouts.yml
+    environment:
+      write_timeout: 20s
+    requests:
+      memory: 64Mi
+    annotations:
+      config-checker.openfaas.com/ignore: of-fn-003
+`
+
+const environmentFile = `environment:
+  read_timeout: 10s
+  write_timeout: 10s
+`
+
+func Test_ReadStack(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stack.yml"), []byte(stackYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "timeouts.yml"), []byte(environmentFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := ReadStack(filepath.Join(dir, "stack.yml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := []string{"openfaas-fn", "staging-fn"}; !reflect.DeepEqual(snapshot.FunctionNamespaces, want) {
+		t.Errorf("want function namespaces %v, got %v", want, snapshot.FunctionNamespaces)
+	}
+
+	resize := snapshot.Functions["openfaas-fn"][0]
+	if resize.Timeout.ReadTimeout != "90" || resize.Scaling.GetZero() != "true" || resize.Scaling.GetZeroDuration() != "2m" {
+		t.Errorf("resize not read as expected: %+v %+v", resize.Timeout, resize.Scaling)
+	}
+	if resize.Requests.Memory != "0" || resize.Limits.Memory != "1Gi" {
+		t.Errorf("want no memory requests and a 1Gi limit, got %s and %s", resize.Requests.Memory, resize.Limits.Memory)
+	}
+
+	env := snapshot.Functions["staging-fn"][0]
+	if env.Timeout.ReadTimeout != "10s" || env.Timeout.WriteTimeout != "20s" {
+		t.Errorf("want read_timeout from environment_file and write_timeout from environment, got %s and %s", env.Timeout.ReadTimeout, env.Timeout.WriteTimeout)
+	}
+	if !reflect.DeepEqual(env.IgnoredRules, []string{"OF-FN-003"}) {
+		t.Errorf("want OF-FN-003 to be ignored, got %v", env.IgnoredRules)
+	}
+}
+
+func Test_AnalyzeFunctions_Stack(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stack.yml"), []byte(stackYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "timeouts.yml"), []byte(environmentFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := ReadStack(filepath.Join(dir, "stack.yml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	snapshot.Components.Gateway.Timeout.Additional["upstream_timeout"] = "60s"
+
+	findings := AnalyzeFunctions(snapshot, DefaultConfig())
+
+	if !hasFinding(findings, "OF-FN-005", "resize.openfaas-fn read_timeout (90) is greater than gateway.upstream_timeout (1m0s)") {
+		t.Errorf("want read_timeout compared with the gateway, got: %v", findings)
+	}
+	if !hasFinding(findings, "OF-FN-006", "resize.openfaas-fn scales down after 2.00 minutes") {
+		t.Errorf("want scale to zero under 5 minutes, got: %v", findings)
+	}
+	if !hasFinding(findings, "OF-FN-008", "resize.openfaas-fn no memory requests set") {
+		t.Errorf("want missing memory requests, got: %v", findings)
+	}
+	if hasFinding(findings, "OF-GW-001", "") {
+		t.Errorf("want no cluster rules to be run, got: %v", findings)
+	}
+}
+
+const cleanStackYAML = `version: 1.0
+provider:
+  name: openfaas
+functions:
+  env:
+    image: ghcr.io/openfaas/alpine:latest
+    environment:
+      read_timeout: 10s
+      write_timeout: 10s
+      exec_timeout: 10s
+    labels:
+      com.openfaas.scale.zero: true
+      com.openfaas.scale.zero-duration: 15m
+    requests:
+      memory: 20Mi
+      cpu: 10m
+    limits:
+      memory: 128Mi
+    readonly_root_filesystem: true
+`
+
+func Test_AnalyzeFunctions_CleanStack(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stack.yml"), []byte(cleanStackYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := ReadStack(filepath.Join(dir, "stack.yml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	snapshot.Components.Gateway.Timeout.Additional["upstream_timeout"] = "60s"
+
+	// Probes are added by the controller at deploy time, so are not checked
+	for _, f := range AnalyzeFunctions(snapshot, DefaultConfig()) {
+		if f.Severity.AtLeast(SeverityWarning) {
+			t.Errorf("want no warnings for a clean stack.yml, got: %s %s", f.RuleID, f.Message)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// values is the subset of the openfaas chart's values.yaml which sets
+// the environment of the core components. Fields which are not listed
+// are ignored.
+//
+// https://github.com/openfaas/faas-netes/tree/master/chart/openfaas
+type values struct {
+	FunctionNamespace string      `json:"functionNamespace"`
+	Async             bool        `json:"async"`
+	OpenFaaSPro       bool        `json:"openfaasPro"`
+	ClusterRole       bool        `json:"clusterRole"`
+	QueueMode         valuesValue `json:"queueMode"`
+
+	Gateway struct {
+		Image           valuesValue `json:"image"`
+		Replicas        int         `json:"replicas"`
+		ReadTimeout     valuesValue `json:"readTimeout"`
+		WriteTimeout    valuesValue `json:"writeTimeout"`
+		UpstreamTimeout valuesValue `json:"upstreamTimeout"`
+		DirectFunctions bool        `json:"directFunctions"`
+		ProbeFunctions  bool        `json:"probeFunctions"`
+	} `json:"gateway"`
+
+	FaasNetes struct {
+		Image          valuesValue `json:"image"`
+		ReadTimeout    valuesValue `json:"readTimeout"`
+		WriteTimeout   valuesValue `json:"writeTimeout"`
+		SetNonRootUser bool        `json:"setNonRootUser"`
+	} `json:"faasnetes"`
+
+	Operator struct {
+		Create bool        `json:"create"`
+		Image  valuesValue `json:"image"`
+	} `json:"operator"`
+
+	QueueWorker struct {
+		Image       valuesValue `json:"image"`
+		Replicas    int         `json:"replicas"`
+		AckWait     valuesValue `json:"ackWait"`
+		MaxInflight int         `json:"maxInflight"`
+	} `json:"queueWorker"`
+
+	JetStreamQueueWorker struct {
+		Image valuesValue `json:"image"`
+	} `json:"jetstreamQueueWorker"`
+
+	Nats struct {
+		External struct {
+			Enabled bool `json:"enabled"`
+		} `json:"external"`
+	} `json:"nats"`
+
+	Autoscaler struct {
+		Enabled  bool        `json:"enabled"`
+		Image    valuesValue `json:"image"`
+		Replicas int         `json:"replicas"`
+	} `json:"autoscaler"`
+
+	Dashboard struct {
+		Enabled          bool        `json:"enabled"`
+		Image            valuesValue `json:"image"`
+		SigningKeySecret valuesValue `json:"signingKeySecret"`
+	} `json:"dashboard"`
+
+	Istio struct {
+		MTLS bool `json:"mtls"`
+	} `json:"istio"`
+}
+
+// valuesValue is a string in values.yaml which may have been written
+// without quotes, such as upstreamTimeout: 60
+type valuesValue string
+
+func (v *valuesValue) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*v = valuesValue(stackValue(value))
+	return nil
+}
+
+// defaultValues are the chart's defaults for the fields which are read,
+// so that a values.yaml which only overrides some of them can be checked.
+func defaultValues() values {
+	v := values{
+		FunctionNamespace: defaultFunctionNamespace,
+		Async:             true,
+	}
+
+	v.Gateway.Replicas = 1
+	v.Gateway.ReadTimeout = "1m05s"
+	v.Gateway.WriteTimeout = "1m05s"
+	v.Gateway.UpstreamTimeout = "1m"
+
+	v.FaasNetes.ReadTimeout = "60s"
+	v.FaasNetes.WriteTimeout = "60s"
+
+	v.QueueWorker.Replicas = 1
+	v.QueueWorker.AckWait = "60s"
+	v.QueueWorker.MaxInflight = 1
+
+	v.Autoscaler.Replicas = 1
+
+	return v
+}
+
+// ReadValues reads one or more of the openfaas chart's values files into
+// a snapshot, later files override earlier ones in the same way as
+// helm's --values flag. Only the core components are set, since
+// functions are not deployed by the chart.
+func ReadValues(paths ...string) (*ClusterSnapshot, error) {
+	v := defaultValues()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Fields which are set in the file replace those which were set
+		// by the defaults or an earlier file, nested fields are merged.
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+		}
+	}
+
+	return v.snapshot(), nil
+}
+
+// snapshot maps the values onto the components in the same way as the
+// chart's templates set their environment.
+func (v values) snapshot() *ClusterSnapshot {
+	snapshot := &ClusterSnapshot{
+		CoreNamespace:      "openfaas",
+		FunctionNamespaces: []string{v.FunctionNamespace},
+		Functions:          map[string][]Function{},
+		Istio:              v.Istio.MTLS,
+	}
+
+	c := &snapshot.Components
+
+	c.Gateway = Gateway{
+		Image:           string(v.Gateway.Image),
+		Replicas:        v.Gateway.Replicas,
+		Timeout:         NewTimeout(),
+		Pro:             v.OpenFaaSPro,
+		DirectFunctions: v.Gateway.DirectFunctions,
+		ProbeFunctions:  v.Gateway.ProbeFunctions,
+	}
+	c.Gateway.Timeout.ReadTimeout = string(v.Gateway.ReadTimeout)
+	c.Gateway.Timeout.WriteTimeout = string(v.Gateway.WriteTimeout)
+	c.Gateway.Timeout.Additional["upstream_timeout"] = string(v.Gateway.UpstreamTimeout)
+
+	c.Controller = Controller{
+		Mode:           "faas-netes",
+		Image:          string(v.FaasNetes.Image),
+		Timeout:        NewTimeout(),
+		SetNonRootUser: v.FaasNetes.SetNonRootUser,
+		ClusterRole:    v.ClusterRole,
+	}
+	if v.Operator.Create {
+		c.Controller.Mode = "operator"
+		c.Controller.Image = string(v.Operator.Image)
+	}
+	c.Controller.Timeout.ReadTimeout = string(v.FaasNetes.ReadTimeout)
+	c.Controller.Timeout.WriteTimeout = string(v.FaasNetes.WriteTimeout)
+
+	if v.Async {
+		queueWorker := &QueueWorker{
+			Image:        string(v.QueueWorker.Image),
+			Replicas:     v.QueueWorker.Replicas,
+			AckWait:      string(v.QueueWorker.AckWait),
+			MaxInflight:  v.QueueWorker.MaxInflight,
+			Timeout:      NewTimeout(),
+			JetStream:    v.QueueMode == "jetstream",
+			InternalNats: !v.Nats.External.Enabled,
+		}
+
+		// The chart gives the queue-worker the gateway's upstream_timeout
+		if queueWorker.JetStream {
+			queueWorker.Image = string(v.JetStreamQueueWorker.Image)
+			queueWorker.Timeout.Additional["upstream_timeout"] = string(v.Gateway.UpstreamTimeout)
+		}
+		c.QueueWorker = queueWorker
+	}
+
+	if v.Autoscaler.Enabled {
+		c.Autoscaler = &Autoscaler{
+			Image:    string(v.Autoscaler.Image),
+			Replicas: v.Autoscaler.Replicas,
+		}
+	}
+
+	if v.Dashboard.Enabled {
+		c.Dashboard = &Dashboard{
+			Image:     string(v.Dashboard.Image),
+			JWTSecret: len(v.Dashboard.SigningKeySecret) > 0,
+		}
+	}
+
+	return snapshot
+}
@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeValues(t *testing.T, dir, name, data string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_ReadValues_Defaults(t *testing.T) {
+	path := writeValues(t, t.TempDir(), "values.yaml", "functionNamespace: openfaas-fn\n")
+
+	snapshot, err := ReadValues(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c := snapshot.Components
+	if c.Gateway.Replicas != 1 || c.Gateway.Timeout.Additional["upstream_timeout"] != "1m" {
+		t.Errorf("want the chart's gateway defaults, got: %+v", c.Gateway)
+	}
+	if c.Controller.Mode != "faas-netes" {
+		t.Errorf("want faas-netes by default, got %s", c.Controller.Mode)
+	}
+	if c.QueueWorker == nil || c.QueueWorker.JetStream || !c.QueueWorker.InternalNats {
+		t.Errorf("want the NATS Streaming queue-worker with internal NATS, got: %+v", c.QueueWorker)
+	}
+}
+
+func Test_ReadValues_LaterFilesTakePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	values := writeValues(t, dir, "values.yaml", `gateway:
+  replicas: 3
+  upstreamTimeout: 2m
+queueWorker:
+  ackWait: 30s
+`)
+	pro := writeValues(t, dir, "values-pro.yaml", `openfaasPro: true
+clusterRole: true
+queueMode: jetstream
+operator:
+  create: true
+gateway:
+  upstreamTimeout: 90
+nats:
+  external:
+    enabled: true
+`)
+
+	snapshot, err := ReadValues(values, pro)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c := snapshot.Components
+	if c.Gateway.Replicas != 3 {
+		t.Errorf("want gateway replicas from values.yaml to be kept, got %d", c.Gateway.Replicas)
+	}
+	if got := c.Gateway.Timeout.Additional["upstream_timeout"]; got != "90" {
+		t.Errorf("want upstream_timeout from values-pro.yaml, got %s", got)
+	}
+	if c.Controller.Mode != "operator" || !c.Controller.ClusterRole || !c.Gateway.Pro {
+		t.Errorf("want the operator, cluster_role and a Pro gateway, got: %+v %+v", c.Controller, c.Gateway)
+	}
+	if !c.QueueWorker.JetStream || c.QueueWorker.InternalNats || c.QueueWorker.AckWait != "30s" {
+		t.Errorf("want JetStream with external NATS, got: %+v", c.QueueWorker)
+	}
+
+	findings := AnalyzeCluster(snapshot, DefaultConfig())
+	if hasFinding(findings, "OF-QW-002", "") {
+		t.Errorf("want the queue-worker to use the gateway's upstream_timeout, got: %v", findings)
+	}
+	if !hasFinding(findings, "OF-QW-007", "queue-worker replicas want >= 3 but got 1") {
+		t.Errorf("want a finding for queue-worker replicas, got: %v", findings)
+	}
+}
@@ -0,0 +1,94 @@
+package checker
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Watchdog is the process which serves HTTP for a function and applies
+// its read_timeout, write_timeout and exec_timeout.
+//
+// https://docs.openfaas.com/architecture/watchdog/
+type Watchdog string
+
+const (
+	// WatchdogUnknown is used when the environment of the Deployment does
+	// not show which watchdog is used, for instance because fprocess is
+	// set in the template's Dockerfile.
+	WatchdogUnknown Watchdog = ""
+
+	// WatchdogClassic forks fprocess for each request
+	WatchdogClassic Watchdog = "classic-watchdog"
+
+	// WatchdogOf runs in a mode such as http or streaming, set by mode
+	WatchdogOf Watchdog = "of-watchdog"
+)
+
+// WatchdogConfig is the watchdog of a function and the environment
+// variables which were used to detect it.
+type WatchdogConfig struct {
+	Watchdog    Watchdog `json:"watchdog,omitempty"`
+	Mode        string   `json:"mode,omitempty"`
+	FProcess    string   `json:"fprocess,omitempty"`
+	UpstreamURL string   `json:"upstreamURL,omitempty"`
+}
+
+// detectWatchdog returns the watchdog which a function uses from its
+// environment. Only the of-watchdog reads mode and upstream_url, so a
+// function which sets neither, but sets fprocess, uses the classic
+// watchdog.
+func detectWatchdog(env map[string]string) WatchdogConfig {
+	config := WatchdogConfig{
+		Mode:        env["mode"],
+		FProcess:    env["fprocess"],
+		UpstreamURL: env["upstream_url"],
+	}
+
+	switch {
+	case len(config.Mode) > 0 || len(config.UpstreamURL) > 0:
+		config.Watchdog = WatchdogOf
+	case len(config.FProcess) > 0:
+		config.Watchdog = WatchdogClassic
+	}
+
+	return config
+}
+
+// defaultTimeout returns the value the watchdog uses when the timeout
+// named by key is not set, false is returned when it is not known.
+func (w Watchdog) defaultTimeout(key string) (time.Duration, bool) {
+	switch w {
+	case WatchdogClassic:
+		switch key {
+		case "read_timeout", "write_timeout":
+			return 5 * time.Second, true
+		case "exec_timeout":
+			return 0, true
+		}
+	case WatchdogOf:
+		switch key {
+		case "read_timeout", "write_timeout", "exec_timeout":
+			return 10 * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// parseTimeout parses a timeout in the same way as the watchdogs, gateway
+// and queue-worker, a bare integer is a number of seconds, otherwise the
+// value is a Go duration such as "1m30s".
+//
+// The watchdogs fall back to their default when a value can not be
+// parsed, which is reported instead of being silently replaced.
+func parseTimeout(name, value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse %s '%s'", name, value)
+	}
+	return d, nil
+}
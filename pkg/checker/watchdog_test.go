@@ -0,0 +1,58 @@
+package checker
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_parseTimeout(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "60", want: 60 * time.Second},
+		{value: "0", want: 0},
+		{value: "1m30s", want: 90 * time.Second},
+		{value: "500ms", want: 500 * time.Millisecond},
+		{value: "-5", wantErr: true},
+		{value: "abc", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, c := range cases {
+		got, err := parseTimeout("read_timeout", c.value)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%q: want an error, got %s", c.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", c.value, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%q: want %s, got %s", c.value, c.want, got)
+		}
+	}
+}
+
+func Test_detectWatchdog(t *testing.T) {
+	cases := []struct {
+		name string
+		env  map[string]string
+		want Watchdog
+	}{
+		{name: "classic", env: map[string]string{"fprocess": "cat"}, want: WatchdogClassic},
+		{name: "of-watchdog mode", env: map[string]string{"fprocess": "node index.js", "mode": "http"}, want: WatchdogOf},
+		{name: "of-watchdog upstream_url", env: map[string]string{"upstream_url": "http://127.0.0.1:5000"}, want: WatchdogOf},
+		{name: "unknown", env: map[string]string{"read_timeout": "10s"}, want: WatchdogUnknown},
+	}
+
+	for _, c := range cases {
+		if got := detectWatchdog(c.env).Watchdog; got != c.want {
+			t.Errorf("%s: want %q, got %q", c.name, c.want, got)
+		}
+	}
+}
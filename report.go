@@ -0,0 +1,415 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"config-checker/pkg/checker"
+)
+
+// Report is the result of checking an OpenFaaS installation, it is
+// printed as text or as JSON with --output json.
+type Report struct {
+	*checker.ClusterSnapshot
+	Features         checker.Features `json:"features"`
+	AsyncConcurrency int              `json:"asyncConcurrency"`
+	TotalFunctions   int              `json:"totalFunctions"`
+
+	// PodSecurity is the Pod Security Standards level each function
+	// namespace would pass
+	PodSecurity map[string]checker.NamespacePodSecurity `json:"podSecurity,omitempty"`
+
+	Warnings []checker.Finding `json:"warnings"`
+
+	// Suppressed are findings for rules ignored through annotations
+	Suppressed []checker.Finding `json:"suppressed"`
+}
+
+func newReport(snapshot *checker.ClusterSnapshot, config checker.Config, findings []checker.Finding) Report {
+	report := Report{
+		ClusterSnapshot:  snapshot,
+		Features:         snapshot.Features(config.Thresholds),
+		AsyncConcurrency: snapshot.AsyncConcurrency(),
+		TotalFunctions:   snapshot.TotalFunctions(),
+		PodSecurity:      snapshot.PodSecurityByNamespace(),
+	}
+	report.Warnings, report.Suppressed = splitSuppressed(findings)
+
+	return report
+}
+
+// splitSuppressed separates the findings which are suppressed, neither
+// slice is nil so that both are printed as JSON arrays.
+func splitSuppressed(findings []checker.Finding) (warnings, suppressed []checker.Finding) {
+	warnings = []checker.Finding{}
+	suppressed = []checker.Finding{}
+
+	for _, finding := range findings {
+		if finding.Suppressed {
+			suppressed = append(suppressed, finding)
+		} else {
+			warnings = append(warnings, finding)
+		}
+	}
+
+	return warnings, suppressed
+}
+
+// installationsReport is printed as JSON, with a report for each OpenFaaS
+// installation which was checked
+type installationsReport struct {
+	Installations []Report `json:"installations"`
+}
+
+// writeJSONReports prints the reports as a single document, which has the
+// same shape however many installations were checked
+func writeJSONReports(w io.Writer, reports []Report) error {
+	return writeJSONReport(w, installationsReport{Installations: reports})
+}
+
+// writeTextReports prints a section for each installation, headed by its
+// namespace when there is more than one
+func writeTextReports(w io.Writer, reports []Report) {
+	for i, report := range reports {
+		if len(reports) > 1 {
+			if i > 0 {
+				fmt.Fprintf(w, "\n")
+			}
+			fmt.Fprintf(w, "Installation %d of %d in namespace %s\n\n", i+1, len(reports), report.CoreNamespace)
+		}
+		writeTextReport(w, report)
+	}
+}
+
+func writeJSONReport(w io.Writer, report interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(report)
+}
+
+func writeTextReport(w io.Writer, report Report) {
+	fmt.Fprintf(w, "OpenFaaS Pro Report\n")
+
+	writeComponents(w, report)
+	writeCoreEvents(w, report.CoreHealth)
+
+	features := report.Features
+
+	fmt.Fprintf(w, `
+Other:
+
+- Kubernetes version: %s
+- Asynchronous concurrency (cluster): %d
+`, report.KubernetesVersion,
+		report.AsyncConcurrency)
+
+	fmt.Fprintf(w, "\n")
+
+	fmt.Fprintf(w, "Total functions in cluster: %d\n\n", report.TotalFunctions)
+
+	for _, namespace := range report.FunctionNamespaces {
+		fmt.Fprintf(w, "\n%d functions in (%s):\n\n", len(report.Functions[namespace]), namespace)
+		functions, ok := report.Functions[namespace]
+		if ok {
+			if len(functions) == 0 {
+				fmt.Fprintf(w, "None detected\n")
+			}
+
+			for _, fn := range functions {
+				printFunction(w, fn, features.Autoscaler)
+			}
+		}
+
+		if workloads := report.OtherWorkloads[namespace]; len(workloads) > 0 {
+			fmt.Fprintf(w, "%d other workloads in (%s), which are not checked:\n\n", len(workloads), namespace)
+			for _, workload := range workloads {
+				fmt.Fprintf(w, "- %s (%d replicas): %s\n", workload.Name, workload.Replicas, strings.Join(workload.Images, ", "))
+			}
+		}
+	}
+
+	writePodSecurity(w, report)
+
+	fmt.Fprintf(w, "\nWarnings:\n\n")
+
+	writeFindings(w, report.Warnings, report.Suppressed)
+}
+
+// writePodSecurity prints the Pod Security Standards level which each
+// function namespace would pass, nothing is printed when no function's
+// Pod spec was evaluated
+func writePodSecurity(w io.Writer, report Report) {
+	if len(report.PodSecurity) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nPod Security Standards:\n\n")
+	for _, namespace := range report.FunctionNamespaces {
+		summary, ok := report.PodSecurity[namespace]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "- %s would pass the %s level (restricted: %d, baseline: %d, privileged: %d)\n", namespace, summary.Level,
+			summary.Restricted, summary.Baseline, summary.Privileged)
+	}
+}
+
+// writeComponents prints the core components, function namespaces and
+// the features which are in use
+func writeComponents(w io.Writer, report Report) {
+	gateway := report.Components.Gateway
+	controller := report.Components.Controller
+
+	fmt.Fprintf(w, "\nGateway\n\n")
+
+	fmt.Fprintf(w, "gateway image: %s\n", gateway.Image)
+	fmt.Fprintf(w, "controller image: %s\n", controller.Image)
+
+	fmt.Fprintf(w, "gateway_replicas: %d\n", gateway.Replicas)
+	fmt.Fprintf(w, "gateway_timeout - read: %s%s write: %s%s upstream: %s%s\n",
+		gateway.Timeout.ReadTimeout, timeoutSource(gateway.Timeout, "read_timeout"),
+		gateway.Timeout.WriteTimeout, timeoutSource(gateway.Timeout, "write_timeout"),
+		gateway.Timeout.Additional["upstream_timeout"], timeoutSource(gateway.Timeout, "upstream_timeout"))
+	fmt.Fprintf(w, "controller_mode: %s\n", controller.Mode)
+	fmt.Fprintf(w, "controller_timeout - read: %s write: %s\n", controller.Timeout.ReadTimeout, controller.Timeout.WriteTimeout)
+
+	if queueWorker := report.Components.QueueWorker; queueWorker != nil {
+		fmt.Fprintf(w, "\nQueue-worker\n\n")
+
+		fmt.Fprintf(w, "queue_worker_image: %s\n", queueWorker.Image)
+		fmt.Fprintf(w, "queue_worker_replicas: %d\n", queueWorker.Replicas)
+		fmt.Fprintf(w, "queue_worker_ack_wait: %s\n", queueWorker.AckWait)
+		fmt.Fprintf(w, "queue_worker_max_inflight: %d\n", queueWorker.MaxInflight)
+		if report.Features.JetStream {
+			// Values which can not be parsed are printed as they were
+			// found and reported by OF-CHK-002
+			if queueWorkerUpstreamTimeout, err := queueWorker.Timeout.GetAdditionalTimeout("upstream_timeout"); err == nil {
+				fmt.Fprintf(w, "queue_worker_upstream_timeout: %s\n", queueWorkerUpstreamTimeout)
+			} else {
+				fmt.Fprintf(w, "queue_worker_upstream_timeout: %s\n", queueWorker.Timeout.Additional["upstream_timeout"])
+			}
+		}
+	}
+
+	if autoscaler := report.Components.Autoscaler; autoscaler != nil {
+		fmt.Fprintf(w, "\nAutoscaler\n\n")
+
+		fmt.Fprintf(w, "autoscaler_image: %s\n", autoscaler.Image)
+	}
+
+	if dashboard := report.Components.Dashboard; dashboard != nil {
+		fmt.Fprintf(w, "\nDashboard\n\n")
+
+		fmt.Fprintf(w, "dashboard_image: %s\n", dashboard.Image)
+	}
+
+	fmt.Fprintf(w, "\nFunction namespaces:\n\n")
+	for _, namespace := range report.FunctionNamespaces {
+		fmt.Fprintf(w, "- %s\n", namespace)
+	}
+
+	features := report.Features
+
+	fmt.Fprintf(w, `
+Features detected:
+
+- %s Async
+- %s Pro gateway
+- %s HA Gateway
+- %s Operator mode
+- %s Autoscaler
+- %s Dashboard
+- %s JetStream
+- %s Istio
+`, icon(features.Async), icon(features.ProGateway), icon(features.HAGateway), icon(features.OperatorMode),
+		icon(features.Autoscaler), icon(features.Dashboard), icon(features.JetStream), icon(features.Istio))
+
+	fmt.Fprintf(w, `
+Advanced features:
+
+- %s Function Builder API
+- %s Multiple namespaces
+`, icon(features.FunctionBuilder), icon(features.MultipleNamespaces))
+}
+
+// writeCoreEvents prints the recurring Warning events of each core
+// component, nothing is printed when there are none
+func writeCoreEvents(w io.Writer, health map[string]*checker.WorkloadHealth) {
+	var names []string
+	for name, h := range health {
+		if h != nil && len(h.Events) > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "\nWarning events:\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "\n%s\n\n", name)
+		writeEvents(w, health[name].Events)
+	}
+}
+
+func writeEvents(w io.Writer, events []checker.EventSummary) {
+	for _, event := range events {
+		fmt.Fprintf(w, "- %dx %s %s/%s: %s\n", event.Count, event.Reason, strings.ToLower(event.Kind), event.Name, event.Message)
+	}
+}
+
+// writeFindings prints each warning, followed by the number of suppressed
+// findings for each rule
+func writeFindings(w io.Writer, warnings, suppressed []checker.Finding) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "%s [%s] %s\n", severityIcon(warning.Severity), warning.RuleID, warning.Message)
+	}
+
+	if len(suppressed) > 0 {
+		fmt.Fprintf(w, "\nSuppressed by %s: %d\n\n", checker.IgnoreAnnotation, len(suppressed))
+
+		counts := map[string]int{}
+		var ids []string
+		for _, finding := range suppressed {
+			if counts[finding.RuleID] == 0 {
+				ids = append(ids, finding.RuleID)
+			}
+			counts[finding.RuleID]++
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			fmt.Fprintf(w, "- %s (%d)\n", id, counts[id])
+		}
+	}
+}
+
+func severityIcon(severity checker.Severity) string {
+	switch severity {
+	case checker.SeverityError:
+		return "❗"
+	case checker.SeverityInfo:
+		return "ℹ️"
+	}
+	return "⚠️"
+}
+
+// writeRules prints each registered rule, for --list-rules
+func writeRules(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID\tSEVERITY\tCATEGORY\tSUMMARY\n")
+	for _, rule := range checker.Rules() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", rule.ID, rule.Severity, rule.Category, rule.Summary)
+	}
+	tw.Flush()
+}
+
+func icon(enabled bool) string {
+	if enabled {
+		return "✅"
+	}
+	return "❌"
+}
+
+func printFunction(out io.Writer, fn checker.Function, autoscaling bool) {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%s\t(%d replicas)\n\n", fn.Name, fn.Replicas)
+
+	if watchdog := fn.Watchdog; watchdog.Watchdog != checker.WatchdogUnknown {
+		if len(watchdog.Mode) > 0 {
+			fmt.Fprintf(w, "- %s\t%s (mode: %s)\n", "watchdog", watchdog.Watchdog, watchdog.Mode)
+		} else {
+			fmt.Fprintf(w, "- %s\t%s\n", "watchdog", watchdog.Watchdog)
+		}
+	}
+
+	if len(fn.Timeout.ReadTimeout) > 0 {
+		fmt.Fprintf(w, "- %s\t%s%s\n", "read_timeout", fn.Timeout.ReadTimeout, timeoutSource(fn.Timeout, "read_timeout"))
+	} else {
+		fmt.Fprintf(w, "- %s\t%s\n", "read_timeout", unsetTimeout(fn.Timeout, "read_timeout"))
+	}
+	if len(fn.Timeout.WriteTimeout) > 0 {
+		fmt.Fprintf(w, "- %s\t%s%s\n", "write_timeout", fn.Timeout.WriteTimeout, timeoutSource(fn.Timeout, "write_timeout"))
+	} else {
+		fmt.Fprintf(w, "- %s\t%s\n", "write_timeout", unsetTimeout(fn.Timeout, "write_timeout"))
+	}
+	if v, ok := fn.Timeout.Additional["exec_timeout"]; ok {
+		fmt.Fprintf(w, "- %s\t%s%s\n", "exec_timeout", v, timeoutSource(fn.Timeout, "exec_timeout"))
+	} else {
+		fmt.Fprintf(w, "- %s\t%s\n", "exec_timeout", unsetTimeout(fn.Timeout, "exec_timeout"))
+	}
+
+	if autoscaling {
+
+		if fn.Scaling == nil {
+			fmt.Fprintf(w, "\nno scaling configuration was set\n")
+		} else {
+			fmt.Fprintf(w, "\nscaling configuration\n")
+
+			fmt.Fprintf(w, "\n- %s\t%s\n", "min/max replicas", fmt.Sprintf("(%s / %s)", fn.Scaling.GetMin(), fn.Scaling.GetMax()))
+			fmt.Fprintf(w, "- %s\t%s\n", "type", fn.Scaling.GetType())
+			fmt.Fprintf(w, "- %s\t%s\n", "target", fn.Scaling.GetTarget())
+			fmt.Fprintf(w, "- %s\t%s\n", "target-proportion", fn.Scaling.GetProportion())
+			fmt.Fprintf(w, "\n")
+
+			if fn.Scaling.GetZero() == "<not set>" || fn.Scaling.GetZero() == "false" {
+				fmt.Fprintf(w, "- %s\t%s\n", "scale to zero", "disabled")
+			} else {
+				fmt.Fprintf(w, "- %s\t%s\n", "scale to zero", fn.Scaling.GetZero())
+				fmt.Fprintf(w, "- %s\t%s\n", "scale to zero duration", fn.Scaling.GetZeroDuration())
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\nresources and limits\n\n")
+
+	printResources(w, "- requests", fn.Requests)
+	printResources(w, "- limits", fn.Limits)
+
+	if fn.Health != nil && len(fn.Health.Events) > 0 {
+		fmt.Fprintf(w, "\nwarning events\n\n")
+		writeEvents(w, fn.Health.Events)
+	}
+
+	fmt.Fprintln(w)
+	w.Flush()
+	fmt.Fprint(out, b.String())
+}
+
+// timeoutSource is printed after a timeout when it was not set directly
+// on the container, such as from a ConfigMap.
+func timeoutSource(timeout *checker.Timeout, name string) string {
+	source, ok := timeout.Sources[name]
+	if !ok || source == "env" {
+		return ""
+	}
+	return fmt.Sprintf(" (from %s)", source)
+}
+
+// unsetTimeout is printed for a timeout without a value, which is either
+// not set or set from a Secret or the Pod's fields which were not read
+func unsetTimeout(timeout *checker.Timeout, name string) string {
+	if timeout.IsUnresolved(name) {
+		return "<unresolved>" + timeoutSource(timeout, name)
+	}
+	return "<not set>"
+}
+
+func printResources(w io.Writer, name string, resources *checker.FunctionResources) {
+	fmt.Fprintf(w, name+":")
+
+	if resources.CPU == "0" && resources.Memory == "0" {
+		fmt.Fprintln(w, "\t <none>")
+		return
+	}
+
+	fmt.Fprintf(w, "\t RAM: %s CPU: %s\n", resources.GetMemory(), resources.GetCpu())
+}
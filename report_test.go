@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"config-checker/pkg/checker"
+)
+
+func Test_writeJSONReport(t *testing.T) {
+	maxInflight := 10
+	snapshot := &checker.ClusterSnapshot{
+		KubernetesVersion: "v1.25.0",
+		Components: checker.Components{
+			Gateway: checker.Gateway{
+				Image:    "ghcr.io/openfaasltd/gateway:0.3.0",
+				Replicas: 3,
+				Timeout:  checker.NewTimeout(),
+			},
+		},
+		FunctionNamespaces: []string{"openfaas-fn"},
+		Functions: map[string][]checker.Function{
+			"openfaas-fn": {
+				{
+					Name:        "env",
+					MaxInflight: &maxInflight,
+					Timeout:     checker.NewTimeout(),
+					Requests:    &checker.FunctionResources{Memory: "20Mi", CPU: "0"},
+					Limits:      &checker.FunctionResources{Memory: "0", CPU: "0"},
+				},
+			},
+		},
+	}
+	report := newReport(snapshot, checker.DefaultConfig(), []checker.Finding{
+		{Message: "gateway replicas want >= 3 but got 1"},
+	})
+
+	var b bytes.Buffer
+	if err := writeJSONReport(&b, report); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Report
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s", err)
+	}
+
+	if got.Components.Gateway.Replicas != 3 {
+		t.Errorf("want 3 gateway replicas, got %d", got.Components.Gateway.Replicas)
+	}
+
+	if got.Components.QueueWorker != nil {
+		t.Errorf("want no queue-worker, got %v", got.Components.QueueWorker)
+	}
+
+	fns := got.Functions["openfaas-fn"]
+	if len(fns) != 1 || *fns[0].MaxInflight != 10 || fns[0].Requests.Memory != "20Mi" {
+		t.Errorf("function was not encoded as expected: %v", fns)
+	}
+
+	if !got.Features.HAGateway || got.TotalFunctions != 1 {
+		t.Errorf("want HA gateway and 1 function, got: %v, %d", got.Features.HAGateway, got.TotalFunctions)
+	}
+
+	if len(got.Warnings) != 1 {
+		t.Errorf("want 1 warning, got %d", len(got.Warnings))
+	}
+}
+
+func Test_writeJSONReports(t *testing.T) {
+	reports := []Report{
+		newReport(&checker.ClusterSnapshot{CoreNamespace: "openfaas"}, checker.DefaultConfig(), nil),
+		newReport(&checker.ClusterSnapshot{CoreNamespace: "team-b"}, checker.DefaultConfig(), nil),
+	}
+
+	// One installation has the same shape as several
+	for _, want := range [][]Report{reports[:1], reports} {
+		var b bytes.Buffer
+		if err := writeJSONReports(&b, want); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var got installationsReport
+		if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+			t.Fatalf("output is not valid JSON: %s", err)
+		}
+		if len(got.Installations) != len(want) || got.Installations[len(want)-1].CoreNamespace != want[len(want)-1].CoreNamespace {
+			t.Errorf("want %d installations, got %s", len(want), b.String())
+		}
+	}
+}
+
+func Test_writeTextReport_PodSecurity(t *testing.T) {
+	function := func(name string, podSecurity *checker.PodSecurity) checker.Function {
+		return checker.Function{
+			Name:        name,
+			Timeout:     checker.NewTimeout(),
+			Requests:    &checker.FunctionResources{Memory: "20Mi", CPU: "0"},
+			Limits:      &checker.FunctionResources{Memory: "0", CPU: "0"},
+			PodSecurity: podSecurity,
+		}
+	}
+
+	snapshot := &checker.ClusterSnapshot{
+		CoreNamespace: "openfaas",
+		Components: checker.Components{
+			Gateway:    checker.Gateway{Timeout: checker.NewTimeout()},
+			Controller: checker.Controller{Timeout: checker.NewTimeout()},
+		},
+		FunctionNamespaces: []string{"openfaas-fn", "staging-fn"},
+		Functions: map[string][]checker.Function{
+			"openfaas-fn": {
+				function("env", &checker.PodSecurity{Level: checker.PodSecurityRestricted}),
+				function("figlet", &checker.PodSecurity{Level: checker.PodSecurityBaseline}),
+			},
+			// Not evaluated, such as from a stack.yml
+			"staging-fn": {
+				function("env", nil),
+			},
+		},
+	}
+	report := newReport(snapshot, checker.DefaultConfig(), nil)
+
+	var text bytes.Buffer
+	writeTextReport(&text, report)
+
+	want := "Pod Security Standards:\n\n- openfaas-fn would pass the baseline level (restricted: 1, baseline: 1, privileged: 0)\n\nWarnings:"
+	if !strings.Contains(text.String(), want) {
+		t.Errorf("want the namespace's level before the warnings, got:\n%s", text.String())
+	}
+
+	var b bytes.Buffer
+	if err := writeJSONReport(&b, report); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var got Report
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %s", err)
+	}
+	if len(got.PodSecurity) != 1 || got.PodSecurity["openfaas-fn"].Level != checker.PodSecurityBaseline {
+		t.Errorf("want the level of openfaas-fn only, got: %+v", got.PodSecurity)
+	}
+}
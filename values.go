@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"config-checker/pkg/checker"
+)
+
+// runCheckValues runs the core component rules against the openfaas
+// chart's values files, for the check-values subcommand
+func runCheckValues(args []string) {
+	var (
+		valuesFiles fileList
+		output      string
+		failOn      string
+		configFile  string
+		redact      bool
+	)
+
+	flags := flag.NewFlagSet("check-values", flag.ExitOnError)
+	flags.Var(&valuesFiles, "f", "Path to a values.yaml for the openfaas chart, can be given more than once and later files take precedence")
+	flags.StringVar(&output, "output", "text", "Output format for the report: text or json")
+	flags.StringVar(&failOn, "fail-on", "", "Exit with a non-zero code when there are findings of this severity or higher: warning or error")
+	flags.StringVar(&configFile, "config", "", "Path to a YAML file to override the thresholds used by the rules")
+	flags.BoolVar(&redact, "redact", false, "Replace the names of functions, namespaces and private registries with hashes, to share the report")
+	flags.Parse(args)
+
+	if len(valuesFiles) == 0 {
+		log.Fatalf("Give at least one values file with -f")
+	}
+
+	checkOutput(output)
+	failOnSeverity := parseFailOn(failOn)
+	config := loadConfig(configFile)
+
+	snapshot, err := checker.ReadValues(valuesFiles...)
+	if err != nil {
+		log.Fatalf("Error reading values: %s", err)
+	}
+
+	findings := checker.AnalyzeCluster(snapshot, config)
+	redactReport(newRedactor(redact), snapshot, findings)
+	report := newReport(snapshot, config, findings)
+
+	if output == "json" {
+		if err := writeJSONReport(os.Stdout, report); err != nil {
+			log.Fatalf("Error writing report: %s", err)
+		}
+	} else {
+		writeValuesReport(os.Stdout, report, valuesFiles)
+	}
+
+	exitOnFindings(findings, failOnSeverity)
+}
+
+func writeValuesReport(w io.Writer, report Report, files []string) {
+	fmt.Fprintf(w, "OpenFaaS values report for %s\n", strings.Join(files, ", "))
+
+	writeComponents(w, report)
+
+	fmt.Fprintf(w, "\nWarnings:\n\n")
+	if len(report.Warnings) == 0 {
+		fmt.Fprintf(w, "None\n")
+	}
+
+	writeFindings(w, report.Warnings, report.Suppressed)
+}